@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxRepositoryCardBatch caps how many repositories go into one document,
+// bigger batches are split over several requests.
+const MaxRepositoryCardBatch = 25
+
+type GithubRepositoryCardRequest struct {
+	Name  string
+	Owner string
+}
+
+// GithubRepositoryCardResult is the outcome of one card of a batch. A
+// repository that failed only sets its own Err.
+type GithubRepositoryCardResult struct {
+	Card GithubRepositoryCardModel
+	Err  error
+}
+
+func repositoryCardAlias(index int) string {
+	return fmt.Sprintf("r%d", index)
+}
+
+// makeRepositoryCardBatchQuery builds one document that looks up every
+// request under its own alias, with names and owners passed as variables.
+func makeRepositoryCardBatchQuery(requests []GithubRepositoryCardRequest) (string, map[string]string) {
+	var parameters, selections strings.Builder
+	variables := map[string]string{}
+	for i, request := range requests {
+		name, owner := fmt.Sprintf("name%d", i), fmt.Sprintf("owner%d", i)
+		if i > 0 {
+			parameters.WriteString(", ")
+		}
+		fmt.Fprintf(&parameters, "$%s: String!, $%s: String!", name, owner)
+		fmt.Fprintf(&selections, "\t%s: repository(name: $%s, owner: $%s) {\n\t\t...RepositoryCard\n\t}\n",
+			repositoryCardAlias(i), name, owner)
+		variables[name] = request.Name
+		variables[owner] = request.Owner
+	}
+	return "query GithubRepositoryCardBatch(" + parameters.String() + ") {\n" + selections.String() + "}", variables
+}
+
+// FetchRepositoryCards looks up many repository cards with as few requests
+// as possible. Results come back in the order of requests.
+func FetchRepositoryCards(ctx context.Context, client *GraphQlClient, requests []GithubRepositoryCardRequest) []GithubRepositoryCardResult {
+	results := make([]GithubRepositoryCardResult, 0, len(requests))
+	for start := 0; start < len(requests); start += MaxRepositoryCardBatch {
+		end := start + MaxRepositoryCardBatch
+		if end > len(requests) {
+			end = len(requests)
+		}
+		results = append(results, fetchRepositoryCardBatch(ctx, client, requests[start:end])...)
+	}
+	return results
+}
+
+func fetchRepositoryCardBatch(ctx context.Context, client *GraphQlClient, requests []GithubRepositoryCardRequest) []GithubRepositoryCardResult {
+	results := make([]GithubRepositoryCardResult, len(requests))
+
+	query, variables := makeRepositoryCardBatchQuery(requests)
+	partialClient := *client
+	partialClient.AllowPartialData = true
+	var queryResult GithubResultModel[map[string]json.RawMessage]
+	err := partialClient.Do(ctx, query, variables, &queryResult)
+	var errData *ErrorData
+	if err != nil && (!errors.As(err, &errData) || len(errData.GithubErrors) == 0) {
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+	githubErrors := queryResult.Errors
+	if errData != nil {
+		githubErrors = errData.GithubErrors
+	}
+
+	errorsByAlias := map[string]GithubErrorModel{}
+	for _, githubError := range githubErrors {
+		if len(githubError.Path) > 0 {
+			alias := fmt.Sprint(githubError.Path[0])
+			if _, ok := errorsByAlias[alias]; !ok {
+				errorsByAlias[alias] = githubError
+			}
+		}
+	}
+
+	for i := range results {
+		alias := repositoryCardAlias(i)
+		if githubError, ok := errorsByAlias[alias]; ok {
+			results[i].Err = &ErrorData{
+				Source:       ErrorDataSourceGithub,
+				Message:      string(classifyGithubErrors([]GithubErrorModel{githubError})),
+				GithubErrors: []GithubErrorModel{githubError},
+			}
+			continue
+		}
+		if errData != nil {
+			results[i].Err = errData
+			continue
+		}
+		raw, ok := queryResult.Data[alias]
+		if !ok || string(raw) == "null" {
+			results[i].Err = &ErrorData{
+				Source:  ErrorDataSourceGithub,
+				Message: string(GraphQlRequestErrorMissingData),
+			}
+			continue
+		}
+		if json.Unmarshal(raw, &results[i].Card.Repository) != nil {
+			results[i].Err = &ErrorData{
+				Source:  ErrorDataSourceUs,
+				Message: string(GraphQlRequestErrorInvalidResponse),
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,140 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestBatchSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestBatchSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestBatchSuite))
+}
+
+const missingRepositoryName = "missing"
+
+// batchHandler answers every alias of a batch document with a repository
+// named after its variable, except for missingRepositoryName.
+func batchHandler(calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var body struct {
+			Query     string            `json:"query"`
+			Variables map[string]string `json:"variables"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &body)
+
+		data := map[string]any{}
+		var errs []map[string]any
+		for i := 0; ; i++ {
+			alias := fmt.Sprintf("r%d", i)
+			if !strings.Contains(body.Query, alias+": repository(") {
+				break
+			}
+			name := body.Variables[fmt.Sprintf("name%d", i)]
+			if name == missingRepositoryName {
+				data[alias] = nil
+				errs = append(errs, map[string]any{
+					"type":    "NOT_FOUND",
+					"path":    []any{alias},
+					"message": "Could not resolve to a Repository with the name 'octocat/missing'.",
+				})
+				continue
+			}
+			data[alias] = map[string]any{"name": name, "stargazerCount": i}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data, "errors": errs})
+	}
+}
+
+func (uts *UnitTestBatchSuite) TestFetchRepositoryCards() {
+
+	var tests = []struct {
+		testName   string
+		names      []string
+		assertFunc func(t *testing.T, calls int32, results []main.GithubRepositoryCardResult)
+	}{
+		{
+			testName: "one bad repository doesn't sink the others",
+			names:    []string{"hello-world", missingRepositoryName, "spoon-knife"},
+			assertFunc: func(t *testing.T, calls int32, results []main.GithubRepositoryCardResult) {
+				asserts := assert.New(t)
+				asserts.EqualValues(1, calls)
+				asserts.Len(results, 3)
+				asserts.Nil(results[0].Err)
+				asserts.Equal("hello-world", results[0].Card.Repository.Name)
+				asserts.NotNil(results[1].Err)
+				asserts.Equal(main.ErrorDataSourceGithub, errorData(results[1].Err).Source)
+				asserts.Nil(results[2].Err)
+				asserts.Equal("spoon-knife", results[2].Card.Repository.Name)
+				asserts.Equal(2, results[2].Card.Repository.StargazerCount)
+			},
+		},
+		{
+			testName: "every repository missing",
+			names:    []string{missingRepositoryName, missingRepositoryName},
+			assertFunc: func(t *testing.T, calls int32, results []main.GithubRepositoryCardResult) {
+				asserts := assert.New(t)
+				for _, result := range results {
+					asserts.NotNil(result.Err)
+					asserts.Equal(string(main.GraphQlRequestErrorNotFound), errorData(result.Err).Message)
+				}
+			},
+		},
+		{
+			testName: "splits large batches",
+			names:    make([]string, main.MaxRepositoryCardBatch+5),
+			assertFunc: func(t *testing.T, calls int32, results []main.GithubRepositoryCardResult) {
+				asserts := assert.New(t)
+				asserts.EqualValues(2, calls)
+				asserts.Len(results, main.MaxRepositoryCardBatch+5)
+				for i, result := range results {
+					asserts.Nil(result.Err)
+					asserts.Equal(fmt.Sprint("repository-", i), result.Card.Repository.Name)
+				}
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(batchHandler(&calls))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			requests := make([]main.GithubRepositoryCardRequest, len(v.names))
+			for i, name := range v.names {
+				if name == "" {
+					name = fmt.Sprint("repository-", i)
+				}
+				requests[i] = main.GithubRepositoryCardRequest{Name: name, Owner: testLogin}
+			}
+
+			// Act
+			results := main.FetchRepositoryCards(context.Background(), client, requests)
+
+			// Assert
+			v.assertFunc(t, atomic.LoadInt32(&calls), results)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores raw successful GraphQL responses by key.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Corrupted uint64
+	// Collections counts the scans of its directory a FileCache made.
+	Collections uint64
+	Entries     int
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache is a Cache bounded to MaxEntries, evicting the least recently
+// used entry first. Expired entries are dropped when they are looked up.
+type MemoryCache struct {
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	stats   CacheStats
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		m.stats.Misses++
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.remove(element)
+		m.stats.Misses++
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	m.stats.Hits++
+	return entry.value, true
+}
+
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+	for m.MaxEntries > 0 && m.order.Len() > m.MaxEntries {
+		m.remove(m.order.Back())
+		m.stats.Evictions++
+	}
+}
+
+func (m *MemoryCache) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Entries = m.order.Len()
+	return stats
+}
+
+func (m *MemoryCache) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryCacheEntry).key)
+}
+
+// cacheKey identifies a response by what was asked and by whom: the same
+// query can answer differently for another token.
+func cacheKey(query string, variables any, tokenIdentity string) (string, bool) {
+	variablesRaw, err := json.Marshal(variables)
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	hash.Write([]byte(normalizeQuery(query)))
+	hash.Write([]byte{0})
+	hash.Write(variablesRaw)
+	hash.Write([]byte{0})
+	hash.Write([]byte(tokenIdentity))
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// normalizeQuery drops comments and insignificant whitespace outside
+// strings, so that formatting alone never splits a cache entry.
+func normalizeQuery(query string) string {
+	var normalized strings.Builder
+	var last byte
+	space := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch ch {
+		case '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = true
+			continue
+		case ' ', '\t', '\r', '\n', ',':
+			space = true
+			continue
+		}
+		if space && last != 0 && isNameByte(last) && isNameByte(ch) {
+			normalized.WriteByte(' ')
+		}
+		space = false
+
+		end := i + 1
+		switch {
+		case strings.HasPrefix(query[i:], `"""`):
+			end = len(query)
+			if closing := strings.Index(query[i+3:], `"""`); closing >= 0 {
+				end = i + 3 + closing + 3
+			}
+		case ch == '"':
+			for end < len(query) && query[end] != '"' {
+				if query[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(query) {
+				end++
+			} else {
+				end = len(query)
+			}
+		}
+		normalized.WriteString(query[i:end])
+		last = query[end-1]
+		i = end - 1
+	}
+	return normalized.String()
+}
+
+// tokenIdentity hashes the Authorization header so keys never hold the
+// token itself.
+func tokenIdentity(headers []RequestHeader) string {
+	for _, head := range headers {
+		if strings.EqualFold(head.key, "Authorization") {
+			sum := sha256.Sum256([]byte(head.value))
+			return hex.EncodeToString(sum[:8])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestCacheSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestCacheSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestCacheSuite))
+}
+
+func (uts *UnitTestCacheSuite) TestMemoryCache() {
+	asserts := assert.New(uts.T())
+
+	cache := main.NewMemoryCache(2)
+	cache.Set("a", []byte("1"), time.Minute)
+	cache.Set("b", []byte("2"), time.Minute)
+	_, ok := cache.Get("a")
+	asserts.True(ok)
+
+	cache.Set("c", []byte("3"), time.Minute)
+	_, ok = cache.Get("b")
+	asserts.False(ok, "least recently used entry should be evicted")
+	value, ok := cache.Get("c")
+	asserts.True(ok)
+	asserts.Equal([]byte("3"), value)
+
+	cache.Set("d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	_, ok = cache.Get("d")
+	asserts.False(ok, "expired entry should be gone")
+
+	stats := cache.Stats()
+	asserts.EqualValues(2, stats.Hits)
+	asserts.EqualValues(2, stats.Misses)
+	asserts.EqualValues(2, stats.Evictions)
+	asserts.Equal(1, stats.Entries)
+}
+
+func (uts *UnitTestCacheSuite) TestClientCache() {
+
+	const testReformattedQuery = `query Viewer($login: String!) {
+		# who am I
+		viewer {
+			login
+		}
+	}`
+
+	var tests = []struct {
+		testName   string
+		status     int
+		ttls       map[string]time.Duration
+		queries    []string
+		variables  []string
+		assertFunc func(t *testing.T, calls int32, errs []*main.ErrorData)
+	}{
+		{
+			testName:  "reuses result of same query",
+			status:    http.StatusOK,
+			queries:   []string{testQuery, testReformattedQuery},
+			variables: []string{testLogin, testLogin},
+			assertFunc: func(t *testing.T, calls int32, errs []*main.ErrorData) {
+				assert.EqualValues(t, 1, calls)
+			},
+		},
+		{
+			testName:  "variables are part of the key",
+			status:    http.StatusOK,
+			queries:   []string{testQuery, testQuery},
+			variables: []string{testLogin, "hubot"},
+			assertFunc: func(t *testing.T, calls int32, errs []*main.ErrorData) {
+				assert.EqualValues(t, 2, calls)
+			},
+		},
+		{
+			testName:  "failures are not cached",
+			status:    http.StatusUnauthorized,
+			queries:   []string{testQuery, testQuery},
+			variables: []string{testLogin, testLogin},
+			assertFunc: func(t *testing.T, calls int32, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.EqualValues(2, calls)
+				asserts.NotNil(errs[1])
+			},
+		},
+		{
+			testName:  "operation ttl overrides default",
+			status:    http.StatusOK,
+			ttls:      map[string]time.Duration{"Viewer": 0},
+			queries:   []string{testQuery, testQuery},
+			variables: []string{testLogin, testLogin},
+			assertFunc: func(t *testing.T, calls int32, errs []*main.ErrorData) {
+				assert.EqualValues(t, 2, calls)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(v.status)
+				if v.status == http.StatusOK {
+					w.Write([]byte(testResponse))
+				} else {
+					w.Write([]byte(`{"message":"Bad credentials"}`))
+				}
+			}))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.Cache = main.NewMemoryCache(10)
+			client.CacheTTL = time.Minute
+			client.CacheTTLs = v.ttls
+
+			// Act
+			errs := make([]*main.ErrorData, len(v.queries))
+			for i := range v.queries {
+				var result graphQlTestResult
+				errs[i] = errorData(client.Do(context.Background(), v.queries[i], map[string]string{"login": v.variables[i]}, &result))
+				if errs[i] == nil {
+					assert.Equal(t, testLogin, result.Data.Viewer.Login)
+				}
+			}
+
+			// Assert
+			v.assertFunc(t, atomic.LoadInt32(&calls), errs)
+		})
+	}
+}
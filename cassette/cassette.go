@@ -0,0 +1,285 @@
+// Package cassette records HTTP exchanges with a GraphQL endpoint to a file
+// and replays them, so code talking to GitHub can be tested offline.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type Mode string
+
+const (
+	// ModeReplay answers requests from the cassette file only.
+	ModeReplay Mode = "replay"
+	// ModeRecord forwards requests and writes the exchanges on Save.
+	ModeRecord Mode = "record"
+)
+
+// ModeEnvKey switches every cassette to ModeRecord when set to "record".
+const ModeEnvKey = "CASSETTE_MODE"
+
+type CassetteError string
+
+const (
+	CassetteErrorNoInteraction CassetteError = "no recorded interaction matches request"
+	CassetteErrorNotRecording  CassetteError = "cassette is not recording"
+)
+
+func (e CassetteError) Error() string {
+	return string(e)
+}
+
+// scrubbedHeaders never reach a cassette file.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+type Request struct {
+	Method    string          `json:"method"`
+	URL       string          `json:"url"`
+	Header    http.Header     `json:"header,omitempty"`
+	Query     string          `json:"query"`
+	Variables json.RawMessage `json:"variables,omitempty"`
+}
+
+type Response struct {
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Text       string          `json:"text,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type file struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper backed by a cassette file. Requests are
+// matched on their GraphQL query and variables, in recording order; once
+// every match was played the last one keeps answering.
+type Recorder struct {
+	Path      string
+	Mode      Mode
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	played       []bool
+}
+
+// New opens the cassette at path. In ModeReplay the file must exist; in
+// ModeRecord exchanges go through transport, http.DefaultTransport if nil.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	recorder := &Recorder{
+		Path:      path,
+		Mode:      mode,
+		Transport: transport,
+	}
+	if mode == ModeRecord {
+		return recorder, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette file
+	if err := json.Unmarshal(raw, &cassette); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	recorder.interactions = cassette.Interactions
+	recorder.played = make([]bool, len(cassette.Interactions))
+	return recorder, nil
+}
+
+// ModeFromEnv returns ModeRecord when ModeEnvKey asks for it and
+// ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if Mode(os.Getenv(ModeEnvKey)) == ModeRecord {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, body, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.Mode == ModeRecord {
+		return r.record(req, request, body)
+	}
+	return r.replay(req, request)
+}
+
+func (r *Recorder) record(req *http.Request, request Request, body []byte) (*http.Response, error) {
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	response, err := r.Transport.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Response{
+		StatusCode: response.StatusCode,
+		Header:     scrub(response.Header),
+	}
+	if json.Valid(responseBody) {
+		recorded.Body = responseBody
+	} else {
+		recorded.Text = string(responseBody)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request:  request,
+		Response: recorded,
+	})
+	r.played = append(r.played, true)
+	r.mu.Unlock()
+
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	return response, nil
+}
+
+func (r *Recorder) replay(req *http.Request, request Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.interactions {
+		if !interaction.Request.matches(request) {
+			continue
+		}
+		match = i
+		if !r.played[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s", CassetteErrorNoInteraction, request.Query)
+	}
+	r.played[match] = true
+
+	recorded := r.interactions[match].Response
+	body := []byte(recorded.Text)
+	if len(recorded.Body) > 0 {
+		// Saving indents the body, hand it back the way it came.
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, recorded.Body); err != nil {
+			return nil, err
+		}
+		body = compacted.Bytes()
+	}
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes every recorded exchange to Path.
+func (r *Recorder) Save() error {
+	if r.Mode != ModeRecord {
+		return CassetteErrorNotRecording
+	}
+	r.mu.Lock()
+	raw, err := json.MarshalIndent(file{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, append(raw, '\n'), 0o644)
+}
+
+func readRequest(req *http.Request) (Request, []byte, error) {
+	request := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: scrub(req.Header),
+	}
+	if req.Body == nil {
+		return request, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return request, nil, err
+	}
+
+	var graphQlBody struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &graphQlBody); err != nil {
+		return request, nil, errors.New("cassette: request body is not a GraphQL query")
+	}
+	request.Query = graphQlBody.Query
+	request.Variables = graphQlBody.Variables
+	return request, body, nil
+}
+
+func (r Request) matches(other Request) bool {
+	return r.Method == other.Method &&
+		normalizeQuery(r.Query) == normalizeQuery(other.Query) &&
+		canonicalJSON(r.Variables) == canonicalJSON(other.Variables)
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// canonicalJSON re-encodes raw so key order and spacing don't matter.
+func canonicalJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "null"
+	}
+	var value any
+	if json.Unmarshal(raw, &value) != nil {
+		return string(raw)
+	}
+	canonical, _ := json.Marshal(value)
+	return string(canonical)
+}
+
+func scrub(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, key := range scrubbedHeaders {
+		scrubbed.Del(key)
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
@@ -0,0 +1,84 @@
+package cassette_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/cassette"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestCassetteSuite struct {
+	suite.Suite
+	path string
+}
+
+func TestUnitTestCassetteSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestCassetteSuite))
+}
+
+const (
+	testToken    = "ghp_secret"
+	testResponse = `{"data":{"viewer":{"login":"octocat"}}}`
+)
+
+func (uts *UnitTestCassetteSuite) SetupTest() {
+	uts.path = filepath.Join(uts.T().TempDir(), "viewer.json")
+}
+
+func post(client *http.Client, url string, body string) (*http.Response, error) {
+	request, _ := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	request.Header.Set("Authorization", "bearer "+testToken)
+	return client.Do(request)
+}
+
+func (uts *UnitTestCassetteSuite) record() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=1")
+		w.Write([]byte(testResponse))
+	}))
+	defer server.Close()
+
+	recorder, err := cassette.New(uts.path, cassette.ModeRecord, server.Client().Transport)
+	uts.Require().Nil(err)
+	response, err := post(&http.Client{Transport: recorder}, server.URL,
+		`{"query":"query { viewer { login } }","variables":{"a":1,"b":2}}`)
+	uts.Require().Nil(err)
+	body, _ := io.ReadAll(response.Body)
+	uts.Equal(testResponse, string(body))
+	uts.Require().Nil(recorder.Save())
+}
+
+func (uts *UnitTestCassetteSuite) Test_RecordScrubsSecrets() {
+	uts.record()
+
+	raw, err := os.ReadFile(uts.path)
+	uts.Require().Nil(err)
+	uts.NotContains(string(raw), testToken)
+	uts.NotContains(string(raw), "session=1")
+}
+
+func (uts *UnitTestCassetteSuite) Test_ReplayMatchesQueryAndVariables() {
+	uts.record()
+
+	recorder, err := cassette.New(uts.path, cassette.ModeReplay, nil)
+	uts.Require().Nil(err)
+	client := &http.Client{Transport: recorder}
+
+	response, err := post(client, "http://offline.invalid/graphql",
+		`{"query":"query {\n  viewer {\n    login\n  }\n}","variables":{"b":2,"a":1}}`)
+	uts.Require().Nil(err)
+	body, _ := io.ReadAll(response.Body)
+	uts.Equal(testResponse, string(body))
+
+	_, err = post(client, "http://offline.invalid/graphql",
+		`{"query":"query { viewer { login } }","variables":{"a":1,"b":3}}`)
+	uts.True(errors.Is(err, cassette.CassetteErrorNoInteraction))
+}
@@ -0,0 +1,34 @@
+// Command fakegithub serves the fakegithub stand-in for the GitHub GraphQL
+// API on a local port, for demos and manual testing without a token.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8081", "address to listen on")
+	fixturesPath := flag.String("fixtures", "", "JSON fixtures file, the bundled ones when empty")
+	token := flag.String("token", "", "token requests must carry, any when empty")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	flag.Parse()
+
+	fixtures := fakegithub.DefaultFixtures()
+	if *fixturesPath != "" {
+		var err error
+		if fixtures, err = fakegithub.LoadFixtures(*fixturesPath); err != nil {
+			log.Fatalln("Couldn't load fixtures:", err)
+		}
+	}
+
+	handler := fakegithub.NewHandler(fixtures)
+	handler.RequireToken(*token)
+	handler.SetLatency(*latency)
+
+	log.Printf("Serving fake GitHub GraphQL API on http://%s/graphql", *addr)
+	log.Fatalln(http.ListenAndServe(*addr, handler))
+}
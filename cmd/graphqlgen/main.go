@@ -0,0 +1,48 @@
+// Command graphqlgen generates typed Go models, variables and query
+// constants from .graphql operation files checked against a schema.
+//
+//	graphqlgen -schema schema/github.graphql -package main -out githubmodels_gen.go queries
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "schema/github.graphql", "SDL file of the schema the operations run against")
+	pkg := flag.String("package", "main", "package of the generated code")
+	out := flag.String("out", "", "file to write the generated code to, stdout when empty")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatalln("Give the .graphql files, or directories holding them, to generate code for")
+	}
+
+	schemaSource, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		log.Fatalln("Couldn't read the schema:", err)
+	}
+	schema, err := graphql.ParseSchema(string(schemaSource))
+	if err != nil {
+		log.Fatalln("Couldn't parse the schema:", err)
+	}
+	sources, err := graphql.ReadSources(flag.Args()...)
+	if err != nil {
+		log.Fatalln("Couldn't read the operations:", err)
+	}
+	code, err := graphql.Generate(schema, *pkg, sources)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatalln("Couldn't write the generated code:", err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/x509"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	GithubAPIURLEnvKey           = "GITHUB_API_URL"
+	GithubAPIURLEnvKeyHelperText = "Base URL of the GitHub API, for GitHub Enterprise Server the URL of the instance" +
+		" (https://github.example.com or https://github.example.com/api/v3)."
+	GithubGraphQLURLEnvKey           = "GITHUB_GRAPHQL_URL"
+	GithubGraphQLURLEnvKeyHelperText = "GraphQL endpoint, when it isn't where " + GithubAPIURLEnvKey + " implies."
+	GithubCABundleEnvKey             = "GITHUB_CA_BUNDLE"
+	GithubCABundleEnvKeyHelperText   = "PEM file of certificate authorities to trust next to the system ones, " +
+		"for instances with a private CA."
+)
+
+const DefaultGithubRESTEndpoint = "https://api.github.com"
+
+type EndpointError string
+
+const (
+	EndpointErrorInvalidURL    EndpointError = "invalid endpoint URL"
+	EndpointErrorInvalidScheme EndpointError = "endpoint must be an http or https URL"
+	EndpointErrorMissingHost   EndpointError = "endpoint has no host"
+	EndpointErrorEmptyCABundle EndpointError = "no certificates found in CA bundle"
+)
+
+func (e EndpointError) Error() string {
+	return string(e)
+}
+
+// GithubEndpoints are the URLs of one GitHub instance.
+type GithubEndpoints struct {
+	GraphQL string
+	REST    string
+}
+
+// ParseGithubAPIURL derives both endpoints from the base URL of an API.
+// api.github.com serves GraphQL at /graphql, while GitHub Enterprise Server
+// serves REST under /api/v3 and GraphQL at /api/graphql, so either the
+// instance URL or its /api/v3 URL is fine for it. An empty apiURL, and the
+// URL of github.com itself, stand for api.github.com.
+func ParseGithubAPIURL(apiURL string) (GithubEndpoints, error) {
+	if empty(strings.TrimSpace(apiURL)) {
+		return GithubEndpoints{
+			GraphQL: APIEndpoint,
+			REST:    DefaultGithubRESTEndpoint,
+		}, nil
+	}
+	parsed, err := parseEndpoint(apiURL)
+	if err != nil {
+		return GithubEndpoints{}, err
+	}
+	parsed.RawQuery, parsed.Fragment = "", ""
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+
+	switch strings.ToLower(parsed.Host) {
+	case "github.com", "www.github.com":
+		return GithubEndpoints{
+			GraphQL: APIEndpoint,
+			REST:    DefaultGithubRESTEndpoint,
+		}, nil
+	case "api.github.com":
+		return GithubEndpoints{
+			GraphQL: parsed.Scheme + "://" + parsed.Host + "/graphql",
+			REST:    parsed.Scheme + "://" + parsed.Host,
+		}, nil
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(parsed.Path, "/api/v3"), "/api")
+	parsed.Path = base + "/api/v3"
+	rest := parsed.String()
+	parsed.Path = base + "/api/graphql"
+	return GithubEndpoints{
+		GraphQL: parsed.String(),
+		REST:    rest,
+	}, nil
+}
+
+// ValidateEndpoint checks that endpoint is an absolute http or https URL,
+// which url.Parse alone lets through far less than that.
+func ValidateEndpoint(endpoint string) error {
+	_, err := parseEndpoint(endpoint)
+	return err
+}
+
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return nil, EndpointErrorInvalidURL
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, EndpointErrorInvalidScheme
+	}
+	if empty(parsed.Host) {
+		return nil, EndpointErrorMissingHost
+	}
+	return parsed, nil
+}
+
+// LoadCABundle returns the system certificate pool with the certificates
+// of the PEM file at path added.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, EndpointErrorEmptyCABundle
+	}
+	return pool, nil
+}
@@ -0,0 +1,136 @@
+package main_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestEndpointsSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestEndpointsSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestEndpointsSuite))
+}
+
+func (uts *UnitTestEndpointsSuite) TestParseGithubAPIURL() {
+
+	var tests = []struct {
+		testName string
+		apiURL   string
+		want     main.GithubEndpoints
+		err      error
+	}{
+		{
+			testName: "github.com by default",
+			want:     main.GithubEndpoints{GraphQL: main.APIEndpoint, REST: main.DefaultGithubRESTEndpoint},
+		},
+		{
+			testName: "github.com",
+			apiURL:   "https://api.github.com/",
+			want:     main.GithubEndpoints{GraphQL: main.APIEndpoint, REST: main.DefaultGithubRESTEndpoint},
+		},
+		{
+			testName: "github.com web url",
+			apiURL:   "https://github.com",
+			want:     main.GithubEndpoints{GraphQL: main.APIEndpoint, REST: main.DefaultGithubRESTEndpoint},
+		},
+		{
+			testName: "www.github.com web url",
+			apiURL:   "https://www.github.com/",
+			want:     main.GithubEndpoints{GraphQL: main.APIEndpoint, REST: main.DefaultGithubRESTEndpoint},
+		},
+		{
+			testName: "enterprise instance",
+			apiURL:   "https://github.example.com",
+			want:     main.GithubEndpoints{GraphQL: "https://github.example.com/api/graphql", REST: "https://github.example.com/api/v3"},
+		},
+		{
+			testName: "enterprise rest url",
+			apiURL:   "https://github.example.com/api/v3/",
+			want:     main.GithubEndpoints{GraphQL: "https://github.example.com/api/graphql", REST: "https://github.example.com/api/v3"},
+		},
+		{
+			testName: "enterprise behind a path",
+			apiURL:   "http://proxy.internal:8080/github/api",
+			want:     main.GithubEndpoints{GraphQL: "http://proxy.internal:8080/github/api/graphql", REST: "http://proxy.internal:8080/github/api/v3"},
+		},
+		{
+			testName: "missing scheme",
+			apiURL:   "github.example.com",
+			err:      main.EndpointErrorInvalidScheme,
+		},
+		{
+			testName: "missing host",
+			apiURL:   "https:///api/v3",
+			err:      main.EndpointErrorMissingHost,
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			endpoints, err := main.ParseGithubAPIURL(v.apiURL)
+			if v.err != nil {
+				uts.ErrorIs(err, v.err)
+				return
+			}
+			uts.Nil(err)
+			uts.Equal(v.want, endpoints)
+		})
+	}
+}
+
+func (uts *UnitTestEndpointsSuite) TestInvalidEndpointIsRefused() {
+	client := main.NewGraphQlClient("not a url", nil)
+	var result graphQlTestResult
+	err := client.Do(context.Background(), testQuery, nil, &result)
+	errData := errorData(err)
+	uts.Require().NotNil(errData)
+	uts.Equal(main.ErrorDataSourceUs, errData.Source)
+	uts.Equal(string(main.GraphQlRequestErrorInvalidEndpoint), errData.Message)
+	uts.ErrorIs(errData, main.EndpointErrorInvalidScheme)
+}
+
+func (uts *UnitTestEndpointsSuite) TestCABundle() {
+	asserts := assert.New(uts.T())
+
+	server := httptest.NewTLSServer(fakegithub.NewHandler(fakegithub.DefaultFixtures()))
+	defer server.Close()
+	endpoints, err := main.ParseGithubAPIURL(server.URL)
+	uts.Require().Nil(err)
+
+	// Without the bundle the certificate of the test server isn't trusted.
+	httpClient, err := main.NewHTTPClient(main.DefaultTransportConfig())
+	uts.Require().Nil(err)
+	_, err = main.FetchRepositoryCard(context.Background(),
+		main.NewGraphQlClient(endpoints.GraphQL, httpClient), "async_button", fakeGithubOwner)
+	asserts.NotNil(err)
+
+	bundle := filepath.Join(uts.T().TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	uts.Require().Nil(os.WriteFile(bundle, certificate, 0o600))
+	config := main.DefaultTransportConfig()
+	config.CABundle = bundle
+	httpClient, err = main.NewHTTPClient(config)
+	uts.Require().Nil(err)
+
+	result, err := main.FetchRepositoryCard(context.Background(),
+		main.NewGraphQlClient(endpoints.GraphQL, httpClient), "async_button", fakeGithubOwner)
+	asserts.Nil(err)
+	asserts.Equal("async_button", result.Data.Repository.Name)
+
+	uts.Require().Nil(os.WriteFile(bundle, []byte("no certificates here"), 0o600))
+	_, err = main.NewHTTPClient(config)
+	asserts.ErrorIs(err, main.EndpointErrorEmptyCABundle)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+)
+
+const githubRequestIDHeader = "X-GitHub-Request-Id"
+
+// Sentinels to match an ErrorData against with errors.Is. Failures that
+// came as a bare HTTP status match the sentinel of that status too.
+var (
+	ErrNotFound          error = GraphQlRequestErrorNotFound
+	ErrForbidden         error = GraphQlRequestErrorForbidden
+	ErrUnauthorized      error = GraphQlRequestErrorUnauthorized
+	ErrRateLimited       error = GraphQlRequestErrorRateLimited
+	ErrRateLimitBudget   error = GraphQlRequestErrorRateLimitBudget
+	ErrCanceled          error = GraphQlRequestErrorCanceled
+	ErrDeadlineExceeded  error = GraphQlRequestErrorDeadlineExceeded
+	ErrAttemptTimeout    error = GraphQlRequestErrorAttemptTimeout
+	ErrInvalidResponse   error = GraphQlRequestErrorInvalidResponse
+	ErrResponseTooLarge  error = GraphQlRequestErrorResponseTooLarge
+	ErrTruncatedResponse error = GraphQlRequestErrorTruncatedResponse
+	ErrMissingData       error = GraphQlRequestErrorMissingData
+	ErrQueryFailed       error = GraphQlRequestErrorQueryFailed
+)
+
+func (e GraphQlRequestErrorMessage) Error() string {
+	return string(e)
+}
+
+func (e RequestHeaderError) Error() string {
+	return string(e)
+}
+
+func (e ReadEnvError) Error() string {
+	return string(e)
+}
+
+func (e *ErrorData) Error() string {
+	message := e.Message
+	if e.StatusCode >= http.StatusBadRequest {
+		message += " (" + http.StatusText(e.StatusCode) + ")"
+	}
+	return string(e.Source) + ": " + message
+}
+
+// asError hands errData out as an error, nil when it is nil, so a nil
+// *ErrorData never turns into a non-nil error.
+func (e *ErrorData) asError() error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+func (e *ErrorData) Unwrap() error {
+	return e.Cause
+}
+
+func (e *ErrorData) Is(target error) bool {
+	kind, ok := target.(GraphQlRequestErrorMessage)
+	if !ok {
+		return false
+	}
+	return GraphQlRequestErrorMessage(e.Message) == kind || e.statusKind() == kind
+}
+
+// statusKind maps the HTTP status of a failed response to the sentinel it
+// stands for. A 403 is a rate limit when its header or message says so.
+func (e *ErrorData) statusKind() GraphQlRequestErrorMessage {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return GraphQlRequestErrorUnauthorized
+	case http.StatusForbidden:
+		if retryableStatus(e.StatusCode, e.Header, e.Message) {
+			return GraphQlRequestErrorRateLimited
+		}
+		return GraphQlRequestErrorForbidden
+	case http.StatusNotFound:
+		return GraphQlRequestErrorNotFound
+	case http.StatusTooManyRequests:
+		return GraphQlRequestErrorRateLimited
+	}
+	return ""
+}
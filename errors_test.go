@@ -0,0 +1,149 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestErrorDataSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestErrorDataSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestErrorDataSuite))
+}
+
+const testDocumentationURL = "https://docs.github.com/graphql"
+
+func (uts *UnitTestErrorDataSuite) TestErrorsIsAndAs() {
+
+	var tests = []struct {
+		testName   string
+		handler    http.HandlerFunc
+		canceled   bool
+		assertFunc func(t *testing.T, err error)
+	}{
+		{
+			testName: "http 401",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message":"Bad credentials","documentation_url":"` + testDocumentationURL + `"}`))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrUnauthorized)
+				asserts.NotErrorIs(err, main.ErrNotFound)
+
+				var errData *main.ErrorData
+				asserts.True(errors.As(err, &errData))
+				asserts.Equal(testDocumentationURL, errData.URL)
+				asserts.Equal(http.StatusUnauthorized, errData.StatusCode)
+				asserts.Equal("ABCD:1234", errData.RequestID)
+				asserts.Equal("Bad credentials", errData.Message)
+			},
+		},
+		{
+			testName: "http 403 with Retry-After",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"You have exceeded a limit, wait a minute."}`))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrRateLimited)
+				asserts.NotErrorIs(err, main.ErrForbidden)
+			},
+		},
+		{
+			testName: "http 403",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrForbidden)
+				asserts.NotErrorIs(err, main.ErrRateLimited)
+			},
+		},
+		{
+			testName: "graphql NOT_FOUND",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{"viewer":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User."}]}`))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrNotFound)
+				asserts.EqualError(err, "github: not found")
+			},
+		},
+		{
+			testName: "canceled",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(testResponse))
+			},
+			canceled: true,
+			assertFunc: func(t *testing.T, err error) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrCanceled)
+				asserts.ErrorIs(err, context.Canceled)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := httptest.NewServer(v.handler)
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if v.canceled {
+				cancel()
+			}
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(ctx, testQuery, nil, &result)
+
+			// Assert
+			assert.NotNil(t, err)
+			if err != nil {
+				v.assertFunc(t, err)
+			}
+		})
+	}
+}
+
+func (uts *UnitTestErrorDataSuite) TestSuccessIsNilError() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testResponse))
+	}))
+	defer server.Close()
+	client := main.NewGraphQlClient(server.URL, server.Client())
+
+	var result graphQlTestResult
+	err := client.Do(context.Background(), testQuery, nil, &result)
+	// A nil *ErrorData inside a non-nil error would fail this.
+	uts.True(err == nil)
+}
+
+func (uts *UnitTestErrorDataSuite) TestReadEnvErrors() {
+	_, err := main.NewReadEnv("", "", main.EnvKey{}, new(main.DefReadEnvEnvironment))
+	uts.ErrorIs(err, main.ReadEnvErrorInvalidKey)
+}
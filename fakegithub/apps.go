@@ -0,0 +1,173 @@
+package fakegithub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultInstallationTokenLifetime is how long GitHub's installation
+// tokens stay valid.
+const DefaultInstallationTokenLifetime = time.Hour
+
+type app struct {
+	publicKey     *rsa.PublicKey
+	installations []string
+}
+
+// AddApp registers a GitHub App. Requests signed with its private key can
+// then list installationIDs and trade them for installation tokens, which
+// the GraphQL endpoint accepts next to the token of RequireToken.
+func (h *Handler) AddApp(appID string, publicKey *rsa.PublicKey, installationIDs ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.apps == nil {
+		h.apps = map[string]app{}
+		h.installationTokens = map[string]time.Time{}
+	}
+	h.apps[appID] = app{
+		publicKey:     publicKey,
+		installations: installationIDs,
+	}
+}
+
+// SetInstallationTokenLifetime changes how long new installation tokens
+// stay valid.
+func (h *Handler) SetInstallationTokenLifetime(lifetime time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.installationTokenLifetime = lifetime
+}
+
+// InstallationTokens tells how many installation tokens were handed out.
+func (h *Handler) InstallationTokens() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.installationTokens)
+}
+
+// acceptsToken reports whether the Authorization header may query the
+// GraphQL endpoint. The caller holds h.mu.
+func (h *Handler) acceptsToken(header string, now time.Time) bool {
+	if h.token == "" && len(h.apps) == 0 {
+		return true
+	}
+	if h.token != "" && authorized(header, h.token) {
+		return true
+	}
+	_, token, _ := strings.Cut(header, " ")
+	expiresAt, ok := h.installationTokens[token]
+	return ok && now.Before(expiresAt)
+}
+
+// serveApp answers the REST endpoints of GitHub Apps, on github.com paths
+// and under the /api/v3 prefix of GitHub Enterprise Server.
+func (h *Handler) serveApp(w http.ResponseWriter, r *http.Request) bool {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	if !strings.HasPrefix(path, "/app/installations") {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	appID, ok := h.verifyJWT(r.Header.Get("Authorization"), now)
+	if !ok {
+		writeMessage(w, http.StatusUnauthorized, "A JSON web token could not be decoded")
+		return true
+	}
+	installations := h.apps[appID].installations
+
+	if path == "/app/installations" && r.Method == http.MethodGet {
+		list := make([]map[string]any, len(installations))
+		for i, installationID := range installations {
+			list[i] = map[string]any{"id": json.Number(installationID), "app_id": json.Number(appID)}
+		}
+		writeJSON(w, http.StatusOK, list)
+		return true
+	}
+
+	installationID := strings.TrimSuffix(strings.TrimPrefix(path, "/app/installations/"), "/access_tokens")
+	if !strings.HasSuffix(path, "/access_tokens") || r.Method != http.MethodPost {
+		writeMessage(w, http.StatusNotFound, "Not Found")
+		return true
+	}
+	for _, known := range installations {
+		if known == installationID {
+			var raw [20]byte
+			rand.Read(raw[:])
+			token := "ghs_" + hex.EncodeToString(raw[:])
+			lifetime := h.installationTokenLifetime
+			if lifetime <= 0 {
+				lifetime = DefaultInstallationTokenLifetime
+			}
+			expiresAt := now.Add(lifetime).Truncate(time.Second)
+			h.installationTokens[token] = expiresAt
+			writeJSON(w, http.StatusCreated, map[string]any{
+				"token":      token,
+				"expires_at": expiresAt.UTC().Format(time.RFC3339),
+			})
+			return true
+		}
+	}
+	writeMessage(w, http.StatusNotFound, "Not Found")
+	return true
+}
+
+// verifyJWT checks an RS256 app JWT the way GitHub does and returns the app
+// it was issued by. The caller holds h.mu.
+func (h *Handler) verifyJWT(header string, now time.Time) (string, bool) {
+	scheme, jwt, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	var jwtHeader struct {
+		Alg string `json:"alg"`
+	}
+	var claims struct {
+		Iat int64 `json:"iat"`
+		Exp int64 `json:"exp"`
+		Iss any   `json:"iss"`
+	}
+	if decodeSegment(parts[0], &jwtHeader) != nil || jwtHeader.Alg != "RS256" || decodeSegment(parts[1], &claims) != nil {
+		return "", false
+	}
+	appID := fmt.Sprint(claims.Iss)
+	registered, ok := h.apps[appID]
+	if !ok {
+		return "", false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(registered.publicKey, crypto.SHA256, digest[:], signature) != nil {
+		return "", false
+	}
+	issuedAt, expiresAt := time.Unix(claims.Iat, 0), time.Unix(claims.Exp, 0)
+	if issuedAt.After(now.Add(time.Minute)) || !now.Before(expiresAt) || expiresAt.Sub(issuedAt) > 10*time.Minute {
+		return "", false
+	}
+	return appID, true
+}
+
+func decodeSegment(segment string, value any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, value)
+}
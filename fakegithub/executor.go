@@ -0,0 +1,297 @@
+package fakegithub
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+// maxPageSize is the most nodes GitHub hands out per connection page.
+const maxPageSize = 100
+
+// executor resolves one operation against the fixtures. Errors found on the
+// way are collected, and invalid marks a document GitHub would have refused
+// before running it.
+type executor struct {
+	document  *graphql.Document
+	variables map[string]any
+	fixtures  Fixtures
+	budget    rateLimitBudget
+	errors    []responseError
+	invalid   bool
+}
+
+func (e *executor) fail(field *graphql.Field, path []any, errorType string, format string, args ...any) {
+	e.errors = append(e.errors, responseError{
+		Type:      errorType,
+		Path:      append([]any(nil), path...),
+		Locations: []graphql.Position{field.Pos},
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (e *executor) stringArgument(field *graphql.Field, name string) string {
+	argument := field.Argument(name)
+	if argument == nil {
+		return ""
+	}
+	value, _ := argument.Value.Resolve(e.variables)
+	s, _ := value.(string)
+	return s
+}
+
+func (e *executor) intArgument(field *graphql.Field, name string) (int, bool) {
+	argument := field.Argument(name)
+	if argument == nil {
+		return 0, false
+	}
+	value, _ := argument.Value.Resolve(e.variables)
+	number, ok := value.(float64)
+	return int(number), ok
+}
+
+func (e *executor) query(selections graphql.SelectionSet) map[string]any {
+	data := map[string]any{}
+	for _, field := range e.fields(selections, "Query") {
+		key := field.ResponseKey()
+		path := []any{key}
+		var object map[string]any
+		switch field.Name {
+		case "__typename":
+			data[key] = "Query"
+			continue
+		case "rateLimit":
+			object = map[string]any{
+				"__typename": "RateLimit",
+				"cost":       1,
+				"limit":      e.budget.limit,
+				"nodeCount":  0,
+				"remaining":  e.budget.remaining,
+				"used":       e.budget.limit - e.budget.remaining,
+				"resetAt":    e.budget.resetAt.UTC().Format("2006-01-02T15:04:05Z"),
+			}
+		case "repository":
+			owner, name := e.stringArgument(field, "owner"), e.stringArgument(field, "name")
+			if object = e.fixtures.repository(owner, name); object == nil {
+				e.fail(field, path, "NOT_FOUND", "Could not resolve to a Repository with the name '%s/%s'.", owner, name)
+			}
+		case "user":
+			login := e.stringArgument(field, "login")
+			if object = e.fixtures.user(login); object == nil {
+				e.fail(field, path, "NOT_FOUND", "Could not resolve to a User with the login of '%s'.", login)
+			}
+		case "organization":
+			login := e.stringArgument(field, "login")
+			if object = e.fixtures.organization(login); object == nil {
+				e.fail(field, path, "NOT_FOUND", "Could not resolve to an Organization with the login of '%s'.", login)
+			}
+		case "repositoryOwner":
+			login := e.stringArgument(field, "login")
+			if object = e.fixtures.user(login); object == nil {
+				object = e.fixtures.organization(login)
+			}
+		default:
+			e.invalid = true
+			e.errors = append(e.errors, responseError{
+				Path:      path,
+				Locations: []graphql.Position{field.Pos},
+				Extensions: map[string]string{
+					"code":      "undefinedField",
+					"typeName":  "Query",
+					"fieldName": field.Name,
+				},
+				Message: fmt.Sprintf("Field '%s' doesn't exist on type 'Query'", field.Name),
+			})
+			continue
+		}
+		if object == nil {
+			data[key] = nil
+			continue
+		}
+		data[key] = e.object(object, field.SelectionSet, path)
+	}
+	return data
+}
+
+// fields flattens fragments into the fields that apply to typeName. An
+// unknown type name matches every type condition.
+func (e *executor) fields(selections graphql.SelectionSet, typeName string) []*graphql.Field {
+	var fields []*graphql.Field
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *graphql.Field:
+			fields = append(fields, selection)
+		case *graphql.InlineFragment:
+			if appliesTo(selection.TypeCondition, typeName) {
+				fields = append(fields, e.fields(selection.SelectionSet, typeName)...)
+			}
+		case *graphql.FragmentSpread:
+			fragment := e.document.Fragment(selection.Name)
+			if fragment != nil && appliesTo(fragment.TypeCondition, typeName) {
+				fields = append(fields, e.fields(fragment.SelectionSet, typeName)...)
+			}
+		}
+	}
+	return fields
+}
+
+func appliesTo(typeCondition string, typeName string) bool {
+	return typeCondition == "" || typeName == "" || typeCondition == typeName
+}
+
+func (e *executor) object(object map[string]any, selections graphql.SelectionSet, path []any) map[string]any {
+	typeName, _ := object["__typename"].(string)
+	toReturn := map[string]any{}
+	for _, field := range e.fields(selections, typeName) {
+		key := field.ResponseKey()
+		if _, done := toReturn[key]; done {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], key)
+		value := object[field.Name]
+		if items, ok := value.([]any); ok && isConnection(field) {
+			connection := e.connection(field, items, fieldPath)
+			if connection == nil {
+				toReturn[key] = nil
+				continue
+			}
+			value = connection
+		}
+		toReturn[key] = e.value(value, field.SelectionSet, fieldPath)
+	}
+	return toReturn
+}
+
+func (e *executor) value(value any, selections graphql.SelectionSet, path []any) any {
+	if len(selections) == 0 {
+		return value
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		return e.object(value, selections, path)
+	case []any:
+		list := make([]any, len(value))
+		for i, item := range value {
+			list[i] = e.value(item, selections, append(path[:len(path):len(path)], i))
+		}
+		return list
+	}
+	return value
+}
+
+// isConnection tells a paginated field from a plain list by its arguments
+// and selections.
+func isConnection(field *graphql.Field) bool {
+	if field.Argument("first") != nil || field.Argument("last") != nil {
+		return true
+	}
+	for _, selection := range field.SelectionSet {
+		if field, ok := selection.(*graphql.Field); ok {
+			switch field.Name {
+			case "nodes", "edges", "pageInfo", "totalCount":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cursor(index int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(index)))
+}
+
+// cursorIndex returns the index a cursor points at, or -1 for anything we
+// didn't hand out.
+func cursorIndex(value string) int {
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || !strings.HasPrefix(string(raw), "cursor:") {
+		return -1
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(string(raw), "cursor:"))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+func (e *executor) connection(field *graphql.Field, items []any, path []any) map[string]any {
+	first, hasFirst := e.intArgument(field, "first")
+	last, hasLast := e.intArgument(field, "last")
+	switch {
+	case !hasFirst && !hasLast:
+		e.fail(field, path, "", "You must provide a `first` or `last` value to properly paginate the `%s` connection.", field.Name)
+		return nil
+	case hasFirst && (first < 0 || first > maxPageSize):
+		e.fail(field, path, "", "Requesting %d records on the `%s` connection exceeds the `first` limit of %d records.", first, field.Name, maxPageSize)
+		return nil
+	case hasLast && (last < 0 || last > maxPageSize):
+		e.fail(field, path, "", "Requesting %d records on the `%s` connection exceeds the `last` limit of %d records.", last, field.Name, maxPageSize)
+		return nil
+	}
+
+	start, end := 0, len(items)
+	if after := e.stringArgument(field, "after"); after != "" {
+		index := cursorIndex(after)
+		if index < 0 {
+			e.fail(field, path, "", "`%s` does not appear to be a valid cursor.", after)
+			return nil
+		}
+		start = min(index+1, end)
+	}
+	if before := e.stringArgument(field, "before"); before != "" {
+		index := cursorIndex(before)
+		if index < 0 {
+			e.fail(field, path, "", "`%s` does not appear to be a valid cursor.", before)
+			return nil
+		}
+		end = max(start, min(index, end))
+	}
+	if hasFirst {
+		end = min(end, start+first)
+	}
+	if hasLast {
+		start = max(start, end-last)
+	}
+
+	nodes := items[start:end]
+	edges := make([]any, len(nodes))
+	for i, node := range nodes {
+		edges[i] = map[string]any{
+			"cursor": cursor(start + i),
+			"node":   node,
+		}
+	}
+	pageInfo := map[string]any{
+		"hasNextPage":     end < len(items),
+		"hasPreviousPage": start > 0,
+		"startCursor":     nil,
+		"endCursor":       nil,
+	}
+	if len(nodes) > 0 {
+		pageInfo["startCursor"] = cursor(start)
+		pageInfo["endCursor"] = cursor(end - 1)
+	}
+	return map[string]any{
+		"totalCount": len(items),
+		"pageInfo":   pageInfo,
+		"nodes":      nodes,
+		"edges":      edges,
+	}
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
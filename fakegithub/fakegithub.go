@@ -0,0 +1,309 @@
+// Package fakegithub is an in-process stand-in for the GitHub GraphQL API.
+// It answers the repository, user and organization lookups our models send
+// from fixture data, and can be told to misbehave the ways GitHub does.
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+const (
+	DefaultRateLimit = 5000
+	documentationURL = "https://docs.github.com/graphql"
+)
+
+// Failure is an HTTP error answered instead of a query. A RetryAfter above
+// zero is sent as the Retry-After header, the way secondary rate limits are.
+type Failure struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+// Handler serves the fake API. Its methods may be called while requests are
+// being served.
+type Handler struct {
+	mu        sync.Mutex
+	fixtures  Fixtures
+	token     string
+	latency   time.Duration
+	limit     int
+	remaining int
+	resetAt   time.Time
+	failures  []Failure
+	requests  int
+
+	apps                      map[string]app
+	installationTokens        map[string]time.Time
+	installationTokenLifetime time.Duration
+}
+
+func NewHandler(fixtures Fixtures) *Handler {
+	return &Handler{
+		fixtures:  fixtures,
+		limit:     DefaultRateLimit,
+		remaining: DefaultRateLimit,
+		resetAt:   time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+}
+
+// Server is a Handler listening on a local httptest server.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+func New(fixtures Fixtures) *Server {
+	handler := NewHandler(fixtures)
+	return &Server{
+		Server:  httptest.NewServer(handler),
+		Handler: handler,
+	}
+}
+
+// Endpoint is the GraphQL URL to point a client at.
+func (s *Server) Endpoint() string {
+	return s.URL + "/graphql"
+}
+
+// RequireToken makes every request without "bearer <token>" fail with 401.
+// An empty token accepts anything, which is the default, unless an app was
+// added with AddApp.
+func (h *Handler) RequireToken(token string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.token = token
+}
+
+// SetLatency delays every response by latency, or until the client gives up.
+func (h *Handler) SetLatency(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latency = latency
+}
+
+// SetRateLimit resets the primary rate limit. Every query costs one point
+// and, once remaining is down to zero, queries fail with RATE_LIMITED until
+// resetAt.
+func (h *Handler) SetRateLimit(limit int, remaining int, resetAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limit = limit
+	h.remaining = remaining
+	h.resetAt = resetAt.Truncate(time.Second)
+}
+
+// FailNext answers the next requests with failures, one each, before going
+// back to normal.
+func (h *Handler) FailNext(failures ...Failure) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = append(h.failures, failures...)
+}
+
+// Requests tells how many requests reached the handler.
+func (h *Handler) Requests() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+type requestBody struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+type responseError struct {
+	Type       string             `json:"type,omitempty"`
+	Path       []any              `json:"path,omitempty"`
+	Locations  []graphql.Position `json:"locations,omitempty"`
+	Extensions map[string]string  `json:"extensions,omitempty"`
+	Message    string             `json:"message"`
+}
+
+type response struct {
+	Data   any             `json:"data,omitempty"`
+	Errors []responseError `json:"errors,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	h.mu.Lock()
+	h.requests++
+	requestID := h.requests
+	latency := h.latency
+	h.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-GitHub-Request-Id", fmt.Sprintf("FAKE:%04X", requestID))
+
+	if h.serveApp(w, r) {
+		return
+	}
+	if r.URL.Path != "/graphql" && r.URL.Path != "/api/graphql" {
+		writeMessage(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	if !h.acceptsToken(r.Header.Get("Authorization"), now) {
+		h.mu.Unlock()
+		writeMessage(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	if len(h.failures) > 0 {
+		failure := h.failures[0]
+		h.failures = h.failures[1:]
+		h.mu.Unlock()
+		writeFailure(w, failure)
+		return
+	}
+	if !now.Before(h.resetAt) {
+		h.remaining = h.limit
+		h.resetAt = now.Add(time.Hour).Truncate(time.Second)
+	}
+	limited := h.remaining <= 0
+	if !limited {
+		h.remaining--
+	}
+	budget := rateLimitBudget{
+		limit:     h.limit,
+		remaining: h.remaining,
+		resetAt:   h.resetAt,
+	}
+	fixtures := h.fixtures
+	h.mu.Unlock()
+
+	budget.writeHeader(w.Header())
+	if limited {
+		writeJSON(w, http.StatusOK, response{Errors: []responseError{{
+			Type:    "RATE_LIMITED",
+			Message: "API rate limit exceeded for user ID 1.",
+		}}})
+		return
+	}
+
+	var request requestBody
+	if err := json.Unmarshal(body, &request); err != nil || request.Query == "" {
+		writeMessage(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	writeJSON(w, http.StatusOK, execute(fixtures, budget, request))
+}
+
+func authorized(header string, token string) bool {
+	scheme, credentials, ok := strings.Cut(header, " ")
+	if !ok {
+		return false
+	}
+	scheme = strings.ToLower(scheme)
+	return (scheme == "bearer" || scheme == "token") && credentials == token
+}
+
+type rateLimitBudget struct {
+	limit     int
+	remaining int
+	resetAt   time.Time
+}
+
+func (b rateLimitBudget) writeHeader(header http.Header) {
+	header.Set("X-RateLimit-Limit", strconv.Itoa(b.limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(b.remaining))
+	header.Set("X-RateLimit-Used", strconv.Itoa(b.limit-b.remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(b.resetAt.Unix(), 10))
+	header.Set("X-RateLimit-Resource", "graphql")
+}
+
+func writeFailure(w http.ResponseWriter, failure Failure) {
+	if failure.RetryAfter > 0 {
+		seconds := int(math.Ceil(failure.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	message := failure.Message
+	if message == "" {
+		message = http.StatusText(failure.StatusCode)
+	}
+	writeMessage(w, failure.StatusCode, message)
+}
+
+func writeMessage(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{
+		"message":           message,
+		"documentation_url": documentationURL,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value any) {
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(value)
+}
+
+func execute(fixtures Fixtures, budget rateLimitBudget, request requestBody) response {
+	document, err := graphql.Parse(request.Query)
+	if err != nil {
+		toReturn := responseError{Message: err.Error()}
+		if syntaxError, ok := err.(*graphql.SyntaxError); ok {
+			toReturn.Message = "Parse error: " + syntaxError.Message
+			toReturn.Locations = []graphql.Position{syntaxError.Pos}
+		}
+		return response{Errors: []responseError{toReturn}}
+	}
+	operation := document.Operation(request.OperationName)
+	if operation == nil {
+		return response{Errors: []responseError{{Message: "An operation name is required"}}}
+	}
+	if operation.Kind != graphql.OperationQuery {
+		return response{Errors: []responseError{{
+			Message:   "fakegithub only answers queries",
+			Locations: []graphql.Position{operation.Pos},
+		}}}
+	}
+
+	variables := map[string]any{}
+	for _, definition := range operation.VariableDefinitions {
+		if definition.Default != nil {
+			variables[definition.Name], _ = definition.Default.Resolve(nil)
+		}
+	}
+	for name, value := range request.Variables {
+		variables[name] = value
+	}
+
+	e := &executor{
+		document:  document,
+		variables: variables,
+		fixtures:  fixtures,
+		budget:    budget,
+	}
+	data := e.query(operation.SelectionSet)
+	if e.invalid {
+		return response{Errors: e.errors}
+	}
+	return response{Data: data, Errors: e.errors}
+}
@@ -0,0 +1,167 @@
+package fakegithub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestFakeGithubSuite struct {
+	suite.Suite
+	server *fakegithub.Server
+}
+
+func TestUnitTestFakeGithubSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestFakeGithubSuite))
+}
+
+func (uts *UnitTestFakeGithubSuite) SetupTest() {
+	uts.server = fakegithub.New(fakegithub.DefaultFixtures())
+}
+
+func (uts *UnitTestFakeGithubSuite) TearDownTest() {
+	uts.server.Close()
+}
+
+type testResult struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Path    []any  `json:"path"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (uts *UnitTestFakeGithubSuite) post(token string, query string, variables map[string]any) (*http.Response, testResult) {
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	request, _ := http.NewRequest(http.MethodPost, uts.server.Endpoint(), bytes.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "bearer "+token)
+	}
+	response, err := uts.server.Client().Do(request)
+	uts.Require().Nil(err)
+	defer response.Body.Close()
+	var result testResult
+	json.NewDecoder(response.Body).Decode(&result)
+	return response, result
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_AliasesFragmentsAndMissingRepositories() {
+	response, result := uts.post("", `query ($owner: String!) {
+		found: repository(owner: $owner, name: "ASYNC_BUTTON") { ...Card }
+		missing: repository(owner: $owner, name: "nope") { ...Card }
+		org: organization(login: "flutter") { __typename login name }
+	}
+	fragment Card on Repository { nameWithOwner stargazerCount owner { login } }`,
+		map[string]any{"owner": "abhisheksrocks"})
+
+	uts.Equal(http.StatusOK, response.StatusCode)
+	uts.JSONEq(`{"nameWithOwner":"abhisheksrocks/async_button","stargazerCount":12,"owner":{"login":"abhisheksrocks"}}`, string(result.Data["found"]))
+	uts.Equal("null", string(result.Data["missing"]))
+	uts.JSONEq(`{"__typename":"Organization","login":"flutter","name":"Flutter"}`, string(result.Data["org"]))
+	uts.Require().Len(result.Errors, 1)
+	uts.Equal("NOT_FOUND", result.Errors[0].Type)
+	uts.Equal([]any{"missing"}, result.Errors[0].Path)
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_PaginatesConnections() {
+	const query = `query ($after: String) {
+		user(login: "abhisheksrocks") {
+			repositories(first: 2, after: $after) {
+				totalCount
+				pageInfo { hasNextPage endCursor }
+				nodes { name }
+			}
+		}
+	}`
+	type page struct {
+		User struct {
+			Repositories struct {
+				TotalCount int `json:"totalCount"`
+				PageInfo   struct {
+					HasNextPage bool    `json:"hasNextPage"`
+					EndCursor   *string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"repositories"`
+		} `json:"user"`
+	}
+
+	var names []string
+	var after any
+	for i := 0; i < 3; i++ {
+		_, result := uts.post("", query, map[string]any{"after": after})
+		uts.Require().Empty(result.Errors)
+		var current page
+		raw, _ := json.Marshal(result.Data)
+		uts.Require().Nil(json.Unmarshal(raw, &current))
+		uts.Equal(3, current.User.Repositories.TotalCount)
+		for _, node := range current.User.Repositories.Nodes {
+			names = append(names, node.Name)
+		}
+		if !current.User.Repositories.PageInfo.HasNextPage {
+			break
+		}
+		after = *current.User.Repositories.PageInfo.EndCursor
+	}
+	uts.Equal([]string{"async_button", "flutter", "readme-studio"}, names)
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_UnknownFieldFailsWholeQuery() {
+	_, result := uts.post("", `{ viewer { login } }`, nil)
+	uts.Nil(result.Data)
+	uts.Require().Len(result.Errors, 1)
+	uts.Contains(result.Errors[0].Message, "Field 'viewer' doesn't exist")
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_RequiresToken() {
+	uts.server.RequireToken("ghp_fake")
+
+	response, _ := uts.post("", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusUnauthorized, response.StatusCode)
+
+	response, result := uts.post("ghp_fake", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusOK, response.StatusCode)
+	uts.JSONEq(`{"remaining":4999}`, string(result.Data["rateLimit"]))
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_RateLimit() {
+	resetAt := time.Now().Add(time.Hour)
+	uts.server.SetRateLimit(10, 1, resetAt)
+
+	response, result := uts.post("", `{ rateLimit { limit remaining used } }`, nil)
+	uts.JSONEq(`{"limit":10,"remaining":0,"used":10}`, string(result.Data["rateLimit"]))
+	uts.Equal("0", response.Header.Get("X-RateLimit-Remaining"))
+
+	response, result = uts.post("", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusOK, response.StatusCode)
+	uts.Require().Len(result.Errors, 1)
+	uts.Equal("RATE_LIMITED", result.Errors[0].Type)
+	uts.Equal("0", response.Header.Get("X-RateLimit-Remaining"))
+	uts.Equal(strconv.FormatInt(resetAt.Unix(), 10), response.Header.Get("X-RateLimit-Reset"))
+}
+
+func (uts *UnitTestFakeGithubSuite) Test_FailNext() {
+	uts.server.FailNext(
+		fakegithub.Failure{StatusCode: http.StatusBadGateway},
+		fakegithub.Failure{StatusCode: http.StatusForbidden, Message: "You have exceeded a secondary rate limit.", RetryAfter: 1500 * time.Millisecond},
+	)
+
+	response, _ := uts.post("", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusBadGateway, response.StatusCode)
+	response, _ = uts.post("", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusForbidden, response.StatusCode)
+	uts.Equal("2", response.Header.Get("Retry-After"))
+	response, _ = uts.post("", `{ rateLimit { remaining } }`, nil)
+	uts.Equal(http.StatusOK, response.StatusCode)
+	uts.Equal(3, uts.server.Requests())
+}
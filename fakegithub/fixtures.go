@@ -0,0 +1,111 @@
+package fakegithub
+
+import (
+	_ "embed"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Fixtures is the data the server answers from, as plain JSON objects with
+// the field names of the GitHub schema. Arrays are served as connections,
+// paginated with first/after and last/before.
+//
+// Repositories are keyed by "owner/name". A user or organization without a
+// "repositories" field owns the repositories filed under its login.
+type Fixtures struct {
+	Repositories  map[string]map[string]any `json:"repositories"`
+	Users         map[string]map[string]any `json:"users"`
+	Organizations map[string]map[string]any `json:"organizations"`
+}
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// DefaultFixtures is a small set of repositories and their owners, enough
+// to run readme-studio against the fake server without a token.
+func DefaultFixtures() Fixtures {
+	var fixtures Fixtures
+	if err := json.Unmarshal(defaultFixtures, &fixtures); err != nil {
+		panic("fakegithub: bad embedded fixtures: " + err.Error())
+	}
+	return fixtures
+}
+
+func LoadFixtures(path string) (Fixtures, error) {
+	var fixtures Fixtures
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fixtures, err
+	}
+	err = json.Unmarshal(raw, &fixtures)
+	return fixtures, err
+}
+
+// repository looks owner/name up the way GitHub does, ignoring case.
+func (f Fixtures) repository(owner string, name string) map[string]any {
+	key := owner + "/" + name
+	for fixtureKey, fixture := range f.Repositories {
+		if strings.EqualFold(fixtureKey, key) {
+			owner, name, _ := strings.Cut(fixtureKey, "/")
+			return withDefaults(fixture, map[string]any{
+				"__typename":    "Repository",
+				"name":          name,
+				"nameWithOwner": fixtureKey,
+				"owner":         map[string]any{"login": owner},
+			})
+		}
+	}
+	return nil
+}
+
+func (f Fixtures) user(login string) map[string]any {
+	return f.owner(f.Users, "User", login)
+}
+
+func (f Fixtures) organization(login string) map[string]any {
+	return f.owner(f.Organizations, "Organization", login)
+}
+
+func (f Fixtures) owner(owners map[string]map[string]any, typeName string, login string) map[string]any {
+	for fixtureLogin, fixture := range owners {
+		if strings.EqualFold(fixtureLogin, login) {
+			return withDefaults(fixture, map[string]any{
+				"__typename":   typeName,
+				"login":        fixtureLogin,
+				"repositories": f.repositoriesOf(fixtureLogin),
+			})
+		}
+	}
+	return nil
+}
+
+// repositoriesOf lists the repositories filed under login, by name.
+func (f Fixtures) repositoriesOf(login string) []any {
+	var keys []string
+	for key := range f.Repositories {
+		if owner, _, _ := strings.Cut(key, "/"); strings.EqualFold(owner, login) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	repositories := make([]any, len(keys))
+	for i, key := range keys {
+		owner, name, _ := strings.Cut(key, "/")
+		repositories[i] = f.repository(owner, name)
+	}
+	return repositories
+}
+
+// withDefaults copies fixture, filling in the fields it leaves out.
+func withDefaults(fixture map[string]any, defaults map[string]any) map[string]any {
+	toReturn := make(map[string]any, len(fixture)+len(defaults))
+	for key, value := range defaults {
+		toReturn[key] = value
+	}
+	for key, value := range fixture {
+		toReturn[key] = value
+	}
+	return toReturn
+}
@@ -0,0 +1,164 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// IntTestFakeGithubSuite runs the models end to end against the in-process
+// stand-in for the GitHub GraphQL API.
+type IntTestFakeGithubSuite struct {
+	suite.Suite
+	server *fakegithub.Server
+	client *main.GraphQlClient
+}
+
+func TestIntTestFakeGithubSuite(t *testing.T) {
+	suite.Run(t, new(IntTestFakeGithubSuite))
+}
+
+const fakeGithubOwner = "abhisheksrocks"
+
+func (its *IntTestFakeGithubSuite) SetupTest() {
+	its.server = fakegithub.New(fakegithub.DefaultFixtures())
+	its.client = main.NewGraphQlClient(its.server.Endpoint(), its.server.Client())
+}
+
+func (its *IntTestFakeGithubSuite) TearDownTest() {
+	its.server.Close()
+}
+
+func (its *IntTestFakeGithubSuite) TestRepositoryCard() {
+	asserts := assert.New(its.T())
+
+	result, err := main.FetchRepositoryCard(context.Background(), its.client, "async_button", fakeGithubOwner)
+	asserts.Nil(err)
+	asserts.Equal("async_button", result.Data.Repository.Name)
+	asserts.Equal(12, result.Data.Repository.StargazerCount)
+	asserts.Len(result.Data.Repository.Languages.Nodes, 1)
+	asserts.Equal("Dart", result.Data.Repository.Languages.Nodes[0].Name)
+
+	result, err = main.FetchRepositoryCard(context.Background(), its.client, "flutter", fakeGithubOwner)
+	asserts.Nil(err)
+	asserts.Equal("flutter/flutter", result.Data.Repository.Parent.NameWithOwner)
+
+	_, err = main.FetchRepositoryCard(context.Background(), its.client, "missing", fakeGithubOwner)
+	asserts.ErrorIs(err, main.ErrNotFound)
+}
+
+func (its *IntTestFakeGithubSuite) TestRepositoryCards() {
+	asserts := assert.New(its.T())
+
+	results := main.FetchRepositoryCards(context.Background(), its.client, []main.GithubRepositoryCardRequest{
+		{Name: "readme-studio", Owner: fakeGithubOwner},
+		{Name: "missing", Owner: fakeGithubOwner},
+		{Name: "flutter", Owner: "flutter"},
+	})
+	asserts.Len(results, 3)
+	asserts.Nil(results[0].Err)
+	asserts.Equal("readme-studio", results[0].Card.Repository.Name)
+	asserts.ErrorIs(results[1].Err, main.ErrNotFound)
+	asserts.Nil(results[2].Err)
+	asserts.Equal(162000, results[2].Card.Repository.StargazerCount)
+	asserts.Equal(1, its.server.Requests())
+}
+
+func (its *IntTestFakeGithubSuite) TestUserRepositories() {
+	asserts := assert.New(its.T())
+
+	paginator := main.NewUserRepositoriesPaginator(its.client, fakeGithubOwner, 0)
+	paginator.PageSize = 2
+	var names []string
+	for page := range paginator.Pages(context.Background()) {
+		asserts.Nil(page.Err)
+		for _, node := range page.Result.Data.User.Repositories.Nodes {
+			names = append(names, node.Name)
+		}
+	}
+	asserts.Equal([]string{"async_button", "flutter", "readme-studio"}, names)
+	asserts.Equal(2, its.server.Requests())
+}
+
+func (its *IntTestFakeGithubSuite) TestMisbehavingGithub() {
+
+	var tests = []struct {
+		testName   string
+		arrange    func(server *fakegithub.Server, client *main.GraphQlClient)
+		assertFunc func(t *testing.T, errData *main.ErrorData, server *fakegithub.Server, client *main.GraphQlClient)
+	}{
+		{
+			testName: "missing token",
+			arrange: func(server *fakegithub.Server, client *main.GraphQlClient) {
+				server.RequireToken("ghp_fake")
+			},
+			assertFunc: func(t *testing.T, errData *main.ErrorData, server *fakegithub.Server, client *main.GraphQlClient) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(errData, main.ErrUnauthorized)
+				asserts.Equal("Bad credentials", errData.Message)
+			},
+		},
+		{
+			testName: "rate limit exhausted",
+			arrange: func(server *fakegithub.Server, client *main.GraphQlClient) {
+				server.SetRateLimit(5000, 0, time.Now().Add(time.Hour))
+				client.RateLimit = main.NewRateLimitTracker(0, main.RateLimitActionRefuse)
+			},
+			assertFunc: func(t *testing.T, errData *main.ErrorData, server *fakegithub.Server, client *main.GraphQlClient) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(errData, main.ErrRateLimited)
+				budget, known := client.RateLimit.Snapshot()
+				asserts.True(known)
+				asserts.Equal(0, budget.Remaining)
+			},
+		},
+		{
+			testName: "slow response",
+			arrange: func(server *fakegithub.Server, client *main.GraphQlClient) {
+				server.SetLatency(time.Second)
+				client.AttemptTimeout = 20 * time.Millisecond
+			},
+			assertFunc: func(t *testing.T, errData *main.ErrorData, server *fakegithub.Server, client *main.GraphQlClient) {
+				assert.ErrorIs(t, errData, main.ErrAttemptTimeout)
+			},
+		},
+		{
+			testName: "bad gateway is retried",
+			arrange: func(server *fakegithub.Server, client *main.GraphQlClient) {
+				server.FailNext(fakegithub.Failure{StatusCode: http.StatusBadGateway})
+				client.Retry = testRetryPolicy
+			},
+			assertFunc: func(t *testing.T, errData *main.ErrorData, server *fakegithub.Server, client *main.GraphQlClient) {
+				asserts := assert.New(t)
+				asserts.Nil(errData)
+				asserts.Equal(2, server.Requests())
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		its.Run(v.testName, func() {
+			t := its.T()
+
+			// Arrange
+			server := fakegithub.New(fakegithub.DefaultFixtures())
+			defer server.Close()
+			client := main.NewGraphQlClient(server.Endpoint(), server.Client())
+			v.arrange(server, client)
+
+			// Act
+			_, err := main.FetchRepositoryCard(context.Background(), client, "async_button", fakeGithubOwner)
+
+			// Assert
+			v.assertFunc(t, errorData(err), server, client)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Defaults of a Fetcher, kept well below what trips GitHub's secondary
+// rate limits.
+const (
+	DefaultFetchConcurrency       = 4
+	DefaultFetchRequestsPerSecond = 10
+)
+
+// Fetcher sends many queries at once, with at most Concurrency of them in
+// flight and no more than RequestsPerSecond started each second. Zero
+// Concurrency stands for one, zero RequestsPerSecond for no limit.
+type Fetcher struct {
+	Client            *GraphQlClient
+	Concurrency       int
+	RequestsPerSecond float64
+}
+
+func NewFetcher(client *GraphQlClient) *Fetcher {
+	return &Fetcher{
+		Client:            client,
+		Concurrency:       DefaultFetchConcurrency,
+		RequestsPerSecond: DefaultFetchRequestsPerSecond,
+	}
+}
+
+type FetchRequest struct {
+	Query     string
+	Variables any
+}
+
+// FetchResult is the outcome of one FetchRequest. A request that failed
+// only sets its own Err.
+type FetchResult[T any] struct {
+	Result GithubResultModel[T]
+	Err    error
+}
+
+// FetchAll sends every request through f and returns their results in the
+// order of requests. Requests still waiting when ctx is done fail with a
+// context ErrorData.
+func FetchAll[T any](ctx context.Context, f *Fetcher, requests []FetchRequest) []FetchResult[T] {
+	results := make([]FetchResult[T], len(requests))
+	f.run(ctx, len(requests), func(ctx context.Context, i int) {
+		results[i].Err = f.Client.Do(ctx, requests[i].Query, requests[i].Variables, &results[i].Result)
+	})
+	return results
+}
+
+// FetchRepositoryCards looks cards up like the FetchRepositoryCards
+// function, but sends the batches through f side by side.
+func (f *Fetcher) FetchRepositoryCards(ctx context.Context, requests []GithubRepositoryCardRequest) []GithubRepositoryCardResult {
+	batches := (len(requests) + MaxRepositoryCardBatch - 1) / MaxRepositoryCardBatch
+	results := make([]GithubRepositoryCardResult, len(requests))
+	f.run(ctx, batches, func(ctx context.Context, i int) {
+		start := i * MaxRepositoryCardBatch
+		end := start + MaxRepositoryCardBatch
+		if end > len(requests) {
+			end = len(requests)
+		}
+		copy(results[start:end], fetchRepositoryCardBatch(ctx, f.Client, requests[start:end]))
+	})
+	return results
+}
+
+// run calls fetch for every index below n from a pool of f.Concurrency
+// workers, paced to f.RequestsPerSecond, and returns once all are done.
+func (f *Fetcher) run(ctx context.Context, n int, fetch func(ctx context.Context, i int)) {
+	workers := f.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+	pacer := newPacer(f.RequestsPerSecond)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Once ctx is done fetch fails right away, which gives
+				// every item left its context error.
+				pacer.wait(ctx)
+				fetch(ctx, i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// pacer spaces calls to wait evenly, so no more than perSecond of them
+// return each second.
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newPacer(perSecond float64) *pacer {
+	if perSecond <= 0 {
+		return &pacer{}
+	}
+	return &pacer{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the caller's turn comes or ctx is done.
+func (p *pacer) wait(ctx context.Context) {
+	if p.interval == 0 {
+		return
+	}
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	turn := p.next
+	p.next = p.next.Add(p.interval)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(turn))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,198 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestFetcherSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestFetcherSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestFetcherSuite))
+}
+
+const missingLogin = "ghost"
+
+// viewerHandler answers with the login it was asked for, after delay, and
+// with NOT_FOUND for missingLogin. It keeps track of the most requests it
+// saw at once in peak.
+func viewerHandler(delay time.Duration, peak *int32) http.HandlerFunc {
+	var running int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(peak)
+			if now <= seen || atomic.CompareAndSwapInt32(peak, seen, now) {
+				break
+			}
+		}
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &body)
+		time.Sleep(delay)
+
+		login := body.Variables["login"]
+		if login == missingLogin {
+			w.Write([]byte(`{"data":{"viewer":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User"}]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"viewer":{"login":%q}}}`, login)
+	}
+}
+
+type viewerModel struct {
+	Viewer struct {
+		Login string `json:"login"`
+	} `json:"viewer"`
+}
+
+func viewerRequests(logins ...string) []main.FetchRequest {
+	requests := make([]main.FetchRequest, len(logins))
+	for i, login := range logins {
+		requests[i] = main.FetchRequest{Query: testQuery, Variables: map[string]string{"login": login}}
+	}
+	return requests
+}
+
+func (uts *UnitTestFetcherSuite) TestFetchAll() {
+	tests := []struct {
+		testName          string
+		concurrency       int
+		requestsPerSecond float64
+		logins            []string
+		assertFunc        func(t *testing.T, results []main.FetchResult[viewerModel], peak int32, took time.Duration)
+	}{
+		{
+			testName:    "results keep the order of requests",
+			concurrency: 3,
+			logins:      []string{"a", "b", missingLogin, "d", "e", "f"},
+			assertFunc: func(t *testing.T, results []main.FetchResult[viewerModel], peak int32, took time.Duration) {
+				asserts := assert.New(t)
+				asserts.Len(results, 6)
+				for i, login := range []string{"a", "b", "", "d", "e", "f"} {
+					asserts.Equal(login, results[i].Result.Data.Viewer.Login)
+				}
+				asserts.ErrorIs(results[2].Err, main.ErrNotFound)
+				asserts.Nil(results[3].Err, "one failure doesn't sink the others")
+			},
+		},
+		{
+			testName:    "concurrency is bounded",
+			concurrency: 2,
+			logins:      []string{"a", "b", "c", "d", "e", "f"},
+			assertFunc: func(t *testing.T, results []main.FetchResult[viewerModel], peak int32, took time.Duration) {
+				assert.LessOrEqual(t, peak, int32(2))
+			},
+		},
+		{
+			testName:          "requests are paced",
+			concurrency:       6,
+			requestsPerSecond: 50,
+			logins:            []string{"a", "b", "c", "d", "e", "f"},
+			assertFunc: func(t *testing.T, results []main.FetchResult[viewerModel], peak int32, took time.Duration) {
+				// Six requests at 50 per second start over at least 100ms.
+				assert.GreaterOrEqual(t, took, 100*time.Millisecond)
+			},
+		},
+		{
+			testName:    "no requests",
+			concurrency: 2,
+			assertFunc: func(t *testing.T, results []main.FetchResult[viewerModel], peak int32, took time.Duration) {
+				assert.Empty(t, results)
+			},
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.testName, func() {
+			// Arrange
+			var peak int32
+			server := httptest.NewServer(viewerHandler(10*time.Millisecond, &peak))
+			defer server.Close()
+			fetcher := &main.Fetcher{
+				Client:            main.NewGraphQlClient(server.URL, server.Client()),
+				Concurrency:       tt.concurrency,
+				RequestsPerSecond: tt.requestsPerSecond,
+			}
+
+			// Act
+			start := time.Now()
+			results := main.FetchAll[viewerModel](context.Background(), fetcher, viewerRequests(tt.logins...))
+
+			// Assert
+			tt.assertFunc(uts.T(), results, atomic.LoadInt32(&peak), time.Since(start))
+		})
+	}
+}
+
+func (uts *UnitTestFetcherSuite) TestFetchAllCanceled() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	var peak int32
+	server := httptest.NewServer(viewerHandler(0, &peak))
+	defer server.Close()
+	fetcher := &main.Fetcher{
+		Client:            main.NewGraphQlClient(server.URL, server.Client()),
+		Concurrency:       1,
+		RequestsPerSecond: 20,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+
+	// Act
+	results := main.FetchAll[viewerModel](ctx, fetcher, viewerRequests("a", "b", "c", "d", "e", "f", "g", "h"))
+
+	// Assert
+	asserts.Nil(results[0].Err)
+	asserts.Equal("a", results[0].Result.Data.Viewer.Login)
+	last := results[len(results)-1]
+	asserts.NotNil(last.Err)
+	asserts.Equal(main.ErrorDataSourceContext, errorData(last.Err).Source)
+}
+
+func (uts *UnitTestFetcherSuite) TestFetchRepositoryCards() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	var calls int32
+	server := httptest.NewServer(batchHandler(&calls))
+	defer server.Close()
+	fetcher := main.NewFetcher(main.NewGraphQlClient(server.URL, server.Client()))
+	requests := make([]main.GithubRepositoryCardRequest, 2*main.MaxRepositoryCardBatch+1)
+	for i := range requests {
+		requests[i] = main.GithubRepositoryCardRequest{Name: fmt.Sprint("repository-", i), Owner: "octocat"}
+	}
+	requests[main.MaxRepositoryCardBatch].Name = missingRepositoryName
+
+	// Act
+	results := fetcher.FetchRepositoryCards(context.Background(), requests)
+
+	// Assert
+	asserts.Equal(int32(3), atomic.LoadInt32(&calls))
+	asserts.Len(results, len(requests))
+	for i, result := range results {
+		if i == main.MaxRepositoryCardBatch {
+			asserts.NotNil(result.Err)
+			continue
+		}
+		asserts.Nil(result.Err)
+		asserts.Equal(fmt.Sprint("repository-", i), result.Card.Repository.Name)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fileCacheExtension = ".json"
+
+// fileCacheEntry is the JSON document stored for every key. Checksum covers
+// Value so that a torn or tampered file is never served.
+type fileCacheEntry struct {
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Checksum  string    `json:"checksum"`
+	Value     []byte    `json:"value"`
+}
+
+// FileCache is a Cache kept as one file per key under Dir, so entries
+// survive restarts. Once the files grow past MaxBytes, expired entries and
+// then the least recently used ones are removed. Which ones is decided from
+// an index of the files built when Dir is scanned, on opening and on
+// Collect, so writes to a full cache don't read the whole directory again.
+type FileCache struct {
+	Dir      string
+	MaxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	index map[string]fileCacheFile
+	stats CacheStats
+}
+
+func NewFileCache(dir string, maxBytes int64) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	cache := &FileCache{
+		Dir:      dir,
+		MaxBytes: maxBytes,
+	}
+	if err := cache.Collect(); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func (f *FileCache) Get(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.path(key)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		f.stats.Misses++
+		return nil, false
+	}
+	var entry fileCacheEntry
+	if json.Unmarshal(raw, &entry) != nil || entry.Key != key || entry.Checksum != checksum(entry.Value) {
+		f.removeFile(path, int64(len(raw)))
+		f.stats.Corrupted++
+		f.stats.Misses++
+		return nil, false
+	}
+	now := time.Now()
+	if now.After(entry.ExpiresAt) {
+		f.removeFile(path, int64(len(raw)))
+		f.stats.Misses++
+		return nil, false
+	}
+	// The modification time doubles as last use for the collector.
+	os.Chtimes(path, now, now)
+	if file, ok := f.index[path]; ok {
+		file.modTime = now
+		f.index[path] = file
+	}
+	f.stats.Hits++
+	return entry.Value, true
+}
+
+func (f *FileCache) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	entry := fileCacheEntry{
+		Key:       key,
+		ExpiresAt: now.Add(ttl),
+		Checksum:  checksum(value),
+		Value:     value,
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.path(key)
+	if writeFileAtomic(path, raw) != nil {
+		return
+	}
+	f.size += int64(len(raw)) - f.index[path].size
+	f.index[path] = fileCacheFile{
+		path:      path,
+		size:      int64(len(raw)),
+		modTime:   now,
+		expiresAt: entry.ExpiresAt,
+	}
+	f.trim(now)
+}
+
+func (f *FileCache) Stats() CacheStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := f.stats
+	stats.Entries = len(f.index)
+	return stats
+}
+
+// Collect scans Dir, removing expired and unreadable entries, then the
+// least recently used ones until the cache fits in MaxBytes. It also picks
+// up entries written to Dir by anything other than this FileCache.
+func (f *FileCache) Collect() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.collect()
+}
+
+type fileCacheFile struct {
+	path      string
+	size      int64
+	modTime   time.Time
+	expiresAt time.Time
+}
+
+func (f *FileCache) collect() error {
+	dirEntries, err := os.ReadDir(f.Dir)
+	if err != nil {
+		return err
+	}
+	f.stats.Collections++
+
+	now := time.Now()
+	index := map[string]fileCacheFile{}
+	var size int64
+	for _, dirEntry := range dirEntries {
+		path := filepath.Join(f.Dir, dirEntry.Name())
+		if strings.HasPrefix(dirEntry.Name(), fileCacheTempPrefix) {
+			// Left behind by a write that never finished.
+			if info, err := dirEntry.Info(); err == nil && now.Sub(info.ModTime()) > fileCacheTempMaxAge {
+				os.Remove(path)
+			}
+			continue
+		}
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != fileCacheExtension {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(path)
+		var entry fileCacheEntry
+		if err != nil || json.Unmarshal(raw, &entry) != nil || entry.Checksum != checksum(entry.Value) {
+			os.Remove(path)
+			f.stats.Corrupted++
+			continue
+		}
+		if now.After(entry.ExpiresAt) {
+			os.Remove(path)
+			continue
+		}
+		index[path] = fileCacheFile{
+			path:      path,
+			size:      info.Size(),
+			modTime:   info.ModTime(),
+			expiresAt: entry.ExpiresAt,
+		}
+		size += info.Size()
+	}
+	f.index, f.size = index, size
+	f.trim(now)
+	return nil
+}
+
+// trim removes expired entries, then the least recently used ones, until
+// the cache fits in MaxBytes. It goes by the index alone.
+func (f *FileCache) trim(now time.Time) {
+	if f.MaxBytes <= 0 || f.size <= f.MaxBytes {
+		return
+	}
+	files := make([]fileCacheFile, 0, len(f.index))
+	for _, file := range f.index {
+		if now.After(file.expiresAt) {
+			f.removeFile(file.path, file.size)
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, file := range files {
+		if f.size <= f.MaxBytes {
+			break
+		}
+		if f.removeFile(file.path, file.size) {
+			f.stats.Evictions++
+		}
+	}
+}
+
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+fileCacheExtension)
+}
+
+// removeFile deletes the entry at path, reporting whether it is gone.
+func (f *FileCache) removeFile(path string, size int64) bool {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if _, ok := f.index[path]; ok {
+		delete(f.index, path)
+		f.size -= size
+	}
+	return true
+}
+
+func checksum(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	fileCacheTempPrefix = ".tmp-"
+	fileCacheTempMaxAge = time.Minute
+)
+
+// writeFileAtomic makes data appear at path all at once: readers see either
+// the old file or the new one, never a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), fileCacheTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
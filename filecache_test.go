@@ -0,0 +1,119 @@
+package main_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestFileCacheSuite struct {
+	suite.Suite
+	dir string
+}
+
+func TestUnitTestFileCacheSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestFileCacheSuite))
+}
+
+func (uts *UnitTestFileCacheSuite) SetupTest() {
+	uts.dir = uts.T().TempDir()
+}
+
+func (uts *UnitTestFileCacheSuite) newCache(maxBytes int64) *main.FileCache {
+	cache, err := main.NewFileCache(uts.dir, maxBytes)
+	uts.Require().Nil(err)
+	return cache
+}
+
+func (uts *UnitTestFileCacheSuite) entryFiles() []string {
+	paths, _ := filepath.Glob(filepath.Join(uts.dir, "*.json"))
+	return paths
+}
+
+func (uts *UnitTestFileCacheSuite) Test_SurvivesRestart() {
+	uts.newCache(0).Set("key", []byte(testResponse), time.Minute)
+
+	value, ok := uts.newCache(0).Get("key")
+	uts.True(ok)
+	uts.Equal([]byte(testResponse), value)
+}
+
+func (uts *UnitTestFileCacheSuite) Test_ExpiredEntry() {
+	cache := uts.newCache(0)
+	cache.Set("key", []byte(testResponse), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	_, ok := cache.Get("key")
+	uts.False(ok)
+	uts.Empty(uts.entryFiles())
+}
+
+func (uts *UnitTestFileCacheSuite) Test_CorruptedEntry() {
+	cache := uts.newCache(0)
+	cache.Set("key", []byte(testResponse), time.Minute)
+	paths := uts.entryFiles()
+	uts.Require().Len(paths, 1)
+
+	raw, _ := os.ReadFile(paths[0])
+	tampered := strings.Replace(string(raw), `"value":"`, `"value":"AAAA`, 1)
+	uts.Require().Nil(os.WriteFile(paths[0], []byte(tampered), 0o600))
+
+	_, ok := cache.Get("key")
+	uts.False(ok)
+	uts.EqualValues(1, cache.Stats().Corrupted)
+	uts.Empty(uts.entryFiles())
+}
+
+func (uts *UnitTestFileCacheSuite) Test_CollectsDownToMaxBytes() {
+	cache := uts.newCache(0)
+	cache.Set("old", []byte(testResponse), time.Minute)
+	paths := uts.entryFiles()
+	uts.Require().Len(paths, 1)
+	info, _ := os.Stat(paths[0])
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(paths[0], past, past)
+
+	cache.MaxBytes = info.Size() + info.Size()/2
+	cache.Set("new", []byte(testResponse), time.Minute)
+
+	_, ok := cache.Get("old")
+	uts.False(ok, "least recently used entry should be collected")
+	_, ok = cache.Get("new")
+	uts.True(ok)
+	uts.EqualValues(1, cache.Stats().Evictions)
+}
+
+func (uts *UnitTestFileCacheSuite) TestFullCacheIsNotRescanned() {
+	// Arrange
+	cache := uts.newCache(0)
+	cache.Set("key-00", []byte(testResponse), time.Minute)
+	paths := uts.entryFiles()
+	uts.Require().Len(paths, 1)
+	info, _ := os.Stat(paths[0])
+	// Entries differ in size by a byte or two, the half entry of slack keeps
+	// five of them fitting whatever their sizes.
+	cache.MaxBytes = 5*info.Size() + info.Size()/2
+
+	// Act
+	for i := 1; i < 50; i++ {
+		cache.Set(fmt.Sprintf("key-%02d", i), []byte(testResponse), time.Minute)
+	}
+
+	// Assert
+	stats := cache.Stats()
+	uts.EqualValues(1, stats.Collections, "only opening the cache scans its directory")
+	uts.EqualValues(45, stats.Evictions)
+	uts.Equal(5, stats.Entries)
+	uts.Len(uts.entryFiles(), 5)
+	_, ok := cache.Get("key-49")
+	uts.True(ok)
+	_, ok = cache.Get("key-00")
+	uts.False(ok)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	GithubAppIDEnvKey           = "GITHUB_APP_ID"
+	GithubAppIDEnvKeyHelperText = "ID of the GitHub App readme-studio runs as, instead of using \"" + GithubTokenEnvKey + "\"." +
+		"\n\tIt is shown on the settings page of the app."
+	GithubAppPrivateKeyEnvKey               = "GITHUB_APP_PRIVATE_KEY"
+	GithubAppPrivateKeyEnvKeyHelperText     = "PEM private key of the GitHub App, or the path of the file holding it."
+	GithubAppInstallationIDEnvKey           = "GITHUB_APP_INSTALLATION_ID"
+	GithubAppInstallationIDEnvKeyHelperText = "Installation of the GitHub App to act as. It can be left out when the app is installed only once."
+)
+
+const (
+	// DefaultGithubAppRefreshBefore is how long before it expires an
+	// installation token is replaced. They live for an hour.
+	DefaultGithubAppRefreshBefore = 5 * time.Minute
+	// githubAppJWTLifetime stays under the ten minutes GitHub accepts, and
+	// githubAppJWTClockSkew backdates tokens for clocks running ahead of ours.
+	githubAppJWTLifetime  = 9 * time.Minute
+	githubAppJWTClockSkew = time.Minute
+)
+
+type GithubAppError string
+
+const (
+	GithubAppErrorInvalidPrivateKey     GithubAppError = "invalid GitHub App private key"
+	GithubAppErrorNoInstallation        GithubAppError = "GitHub App is not installed anywhere"
+	GithubAppErrorAmbiguousInstallation GithubAppError = "GitHub App is installed more than once, pick one with " + GithubAppInstallationIDEnvKey
+	GithubAppErrorInvalidTokenResponse  GithubAppError = "couldn't parse installation token response"
+)
+
+func (e GithubAppError) Error() string {
+	return string(e)
+}
+
+// GithubAppAuth authenticates as an installation of a GitHub App. It signs
+// a JWT with the private key of the app and trades it at RESTEndpoint for
+// an installation token, which is reused until RefreshBefore ahead of its
+// expiry.
+type GithubAppAuth struct {
+	AppID          string
+	InstallationID string
+	PrivateKey     *rsa.PrivateKey
+	RESTEndpoint   string
+	HTTPClient     *http.Client
+	RefreshBefore  time.Duration
+	// RateLimit is optional. It follows the budget of the installation,
+	// which all of its tokens share, and guards requests the way a
+	// GraphQlClient does.
+	RateLimit *RateLimitTracker
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewGithubAppAuth(appID string, installationID string, privateKeyPEM []byte) (*GithubAppAuth, error) {
+	privateKey, err := ParseGithubAppPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &GithubAppAuth{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     privateKey,
+		RESTEndpoint:   DefaultGithubRESTEndpoint,
+		RefreshBefore:  DefaultGithubAppRefreshBefore,
+	}, nil
+}
+
+// ParseGithubAppPrivateKey reads the PKCS#1 key GitHub hands out, or the
+// same key converted to PKCS#8.
+func ParseGithubAppPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, GithubAppErrorInvalidPrivateKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, GithubAppErrorInvalidPrivateKey
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, GithubAppErrorInvalidPrivateKey
+	}
+	return rsaKey, nil
+}
+
+// JWT returns the token that authenticates as the app itself, signed with
+// RS256.
+func (a *GithubAppAuth) JWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-githubAppJWTClockSkew).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": a.AppID,
+	})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Token returns an installation token that is good for at least
+// RefreshBefore, fetching a new one when needed.
+func (a *GithubAppAuth) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if notEmpty(a.token) && now.Add(a.RefreshBefore).Before(a.expiresAt) {
+		return a.token, nil
+	}
+	if empty(a.InstallationID) {
+		installationID, err := a.findInstallation(ctx, now)
+		if err != nil {
+			return "", err
+		}
+		a.InstallationID = installationID
+	}
+
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := a.call(ctx, now, http.MethodPost, "/app/installations/"+a.InstallationID+"/access_tokens", &installationToken); err != nil {
+		return "", err
+	}
+	if empty(installationToken.Token) {
+		return "", GithubAppErrorInvalidTokenResponse
+	}
+	a.token, a.expiresAt = installationToken.Token, installationToken.ExpiresAt
+	return a.token, nil
+}
+
+// forget drops the current installation token, so the next request gets a
+// new one.
+func (a *GithubAppAuth) forget(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == token {
+		a.token = ""
+	}
+}
+
+func (a *GithubAppAuth) findInstallation(ctx context.Context, now time.Time) (string, error) {
+	var installations []struct {
+		ID json.Number `json:"id"`
+	}
+	if err := a.call(ctx, now, http.MethodGet, "/app/installations", &installations); err != nil {
+		return "", err
+	}
+	switch len(installations) {
+	case 0:
+		return "", GithubAppErrorNoInstallation
+	case 1:
+		return installations[0].ID.String(), nil
+	}
+	return "", GithubAppErrorAmbiguousInstallation
+}
+
+// call sends a REST request authenticated as the app and decodes the
+// answer into result. Failures come back as *ErrorData.
+func (a *GithubAppAuth) call(ctx context.Context, now time.Time, method string, path string, result any) error {
+	jwt, err := a.JWT(now)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(a.RESTEndpoint, "/")+path, nil)
+	if err != nil {
+		return &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GraphQlRequestErrorInvalidEndpoint),
+			Cause:   err,
+		}
+	}
+	request.Header.Set("Authorization", "Bearer "+jwt)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("User-Agent", UserAgent)
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		if contextError := contextErrorData(ctx, ctx); contextError != nil {
+			return contextError
+		}
+		return &ErrorData{
+			Source:  ErrorDataSourceUnknown,
+			Message: (err.Error()),
+			Cause:   err,
+		}
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GraphQlRequestErrorInvalidResponse),
+			Cause:   err,
+		}
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= 300 {
+		var possibleGithubError GithubError
+		json.Unmarshal(data, &possibleGithubError)
+		toReturn := possibleGithubError.toErrorData()
+		if empty(toReturn.Message) {
+			toReturn.Message = http.StatusText(response.StatusCode)
+		}
+		toReturn.StatusCode = response.StatusCode
+		toReturn.RequestID = response.Header.Get(githubRequestIDHeader)
+		toReturn.Header = response.Header
+		return &toReturn
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GithubAppErrorInvalidTokenResponse),
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// Identity stands for the installation in cache keys.
+func (a *GithubAppAuth) Identity() string {
+	return "app:" + a.AppID + "/" + a.InstallationID
+}
+
+// Status tells what RateLimit knows about the budget of the installation,
+// nothing when it isn't set.
+func (a *GithubAppAuth) Status() []TokenStatus {
+	if a.RateLimit == nil {
+		return nil
+	}
+	budget, known := a.RateLimit.Snapshot()
+	return []TokenStatus{{
+		Identity:    a.Identity(),
+		Budget:      budget,
+		BudgetKnown: known,
+	}}
+}
+
+// Middleware authorizes every request with the installation token. A
+// request refused with 401 drops the token, so the next one gets a new one.
+func (a *GithubAppAuth) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if a.RateLimit != nil {
+				claim := rateLimitClaimFrom(request.Context())
+				cost := claim.estimatedCost()
+				if errData := a.RateLimit.reserve(request.Context(), cost); errData != nil {
+					return nil, errData
+				}
+				defer a.RateLimit.settle(cost)
+				claim.observedBy(a.RateLimit)
+			}
+			token, err := a.Token(request.Context())
+			var errData *ErrorData
+			if err != nil && !errors.As(err, &errData) {
+				return nil, &ErrorData{
+					Source:  ErrorDataSourceUs,
+					Message: err.Error(),
+					Cause:   err,
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			header := makeAuthorizationHeader(token)
+			request = request.Clone(request.Context())
+			request.Header.Set(header.key, header.value)
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				return response, err
+			}
+			if a.RateLimit != nil {
+				a.RateLimit.observeHeader(response.Header, time.Now())
+			}
+			if response.StatusCode == http.StatusUnauthorized {
+				a.forget(token)
+			}
+			return response, nil
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestGithubAppSuite struct {
+	suite.Suite
+	privateKey *rsa.PrivateKey
+}
+
+func TestUnitTestGithubAppSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestGithubAppSuite))
+}
+
+const (
+	testAppID          = "123456"
+	testInstallationID = "42"
+)
+
+func (uts *UnitTestGithubAppSuite) SetupSuite() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	uts.Require().Nil(err)
+	uts.privateKey = privateKey
+}
+
+func (uts *UnitTestGithubAppSuite) privateKeyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(uts.privateKey),
+	})
+}
+
+func (uts *UnitTestGithubAppSuite) TestParsePrivateKey() {
+	asserts := assert.New(uts.T())
+
+	_, err := main.ParseGithubAppPrivateKey(uts.privateKeyPEM())
+	asserts.Nil(err)
+
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(uts.privateKey)
+	_, err = main.ParseGithubAppPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	asserts.Nil(err)
+
+	_, err = main.ParseGithubAppPrivateKey([]byte("not a key"))
+	asserts.ErrorIs(err, main.GithubAppErrorInvalidPrivateKey)
+}
+
+func (uts *UnitTestGithubAppSuite) TestJWT() {
+	asserts := assert.New(uts.T())
+
+	appAuth, err := main.NewGithubAppAuth(testAppID, testInstallationID, uts.privateKeyPEM())
+	uts.Require().Nil(err)
+	now := time.Unix(1700000000, 0)
+	jwt, err := appAuth.JWT(now)
+	uts.Require().Nil(err)
+
+	parts := strings.Split(jwt, ".")
+	uts.Require().Len(parts, 3)
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	asserts.Nil(json.Unmarshal(raw, &claims))
+	asserts.Equal(testAppID, claims.Iss)
+	asserts.Less(claims.Iat, now.Unix())
+	asserts.LessOrEqual(claims.Exp-claims.Iat, int64(10*time.Minute/time.Second))
+}
+
+func (uts *UnitTestGithubAppSuite) TestInstallationTokens() {
+
+	var tests = []struct {
+		testName       string
+		installationID string
+		installations  []string
+		lifetime       time.Duration
+		otherKey       bool
+		assertFunc     func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData)
+	}{
+		{
+			testName:       "reuses token until it gets old",
+			installationID: testInstallationID,
+			installations:  []string{testInstallationID},
+			assertFunc: func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				asserts.Nil(errs[1])
+				asserts.Equal(1, server.InstallationTokens())
+			},
+		},
+		{
+			testName:       "refreshes token before expiry",
+			installationID: testInstallationID,
+			installations:  []string{testInstallationID},
+			lifetime:       time.Minute,
+			assertFunc: func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				asserts.Nil(errs[1])
+				asserts.Equal(2, server.InstallationTokens())
+			},
+		},
+		{
+			testName:      "finds the only installation",
+			installations: []string{testInstallationID},
+			assertFunc: func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				asserts.Equal(1, server.InstallationTokens())
+			},
+		},
+		{
+			testName:      "several installations",
+			installations: []string{testInstallationID, "43"},
+			assertFunc: func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(errs[0], main.GithubAppErrorAmbiguousInstallation)
+				asserts.Equal(main.ErrorDataSourceUs, errs[0].Source)
+			},
+		},
+		{
+			testName:       "key of another app",
+			installationID: testInstallationID,
+			installations:  []string{testInstallationID},
+			otherKey:       true,
+			assertFunc: func(t *testing.T, server *fakegithub.Server, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(errs[0], main.ErrUnauthorized)
+				asserts.Equal(0, server.InstallationTokens())
+				asserts.Equal(2, server.Requests(), "only the token endpoint is asked")
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := fakegithub.New(fakegithub.DefaultFixtures())
+			defer server.Close()
+			publicKey := &uts.privateKey.PublicKey
+			if v.otherKey {
+				otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+				uts.Require().Nil(err)
+				publicKey = &otherKey.PublicKey
+			}
+			server.AddApp(testAppID, publicKey, v.installations...)
+			server.SetInstallationTokenLifetime(v.lifetime)
+
+			appAuth, err := main.NewGithubAppAuth(testAppID, v.installationID, uts.privateKeyPEM())
+			uts.Require().Nil(err)
+			appAuth.RESTEndpoint = server.URL
+			appAuth.HTTPClient = server.Client()
+			client := main.NewGraphQlClient(server.Endpoint(), server.Client(), appAuth.Middleware())
+
+			// Act
+			errs := make([]*main.ErrorData, 2)
+			for i := range errs {
+				_, err := main.FetchRepositoryCard(context.Background(), client, "async_button", fakeGithubOwner)
+				errs[i] = errorData(err)
+			}
+
+			// Assert
+			v.assertFunc(t, server, errs)
+		})
+	}
+}
+
+func (uts *UnitTestGithubAppSuite) TestInstallationRateLimit() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	server := fakegithub.New(fakegithub.DefaultFixtures())
+	defer server.Close()
+	server.AddApp(testAppID, &uts.privateKey.PublicKey, testInstallationID)
+	server.SetRateLimit(5000, 51, time.Now().Add(time.Hour))
+
+	appAuth, err := main.NewGithubAppAuth(testAppID, testInstallationID, uts.privateKeyPEM())
+	uts.Require().Nil(err)
+	appAuth.RESTEndpoint = server.URL
+	appAuth.HTTPClient = server.Client()
+	appAuth.RateLimit = main.NewRateLimitTracker(50, main.RateLimitActionRefuse)
+	client := main.NewGraphQlClient(server.Endpoint(), server.Client(), appAuth.Middleware())
+	client.InjectRateLimit = true
+	clientMetrics := main.NewMetrics()
+	clientMetrics.WatchGithubApp(appAuth)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, _, err := main.ServeMetrics(ctx, "127.0.0.1:0", clientMetrics, nil)
+	uts.Require().Nil(err)
+
+	// Act
+	errs := make([]*main.ErrorData, 2)
+	for i := range errs {
+		_, err := main.FetchRepositoryCard(context.Background(), client, "async_button", fakeGithubOwner)
+		errs[i] = errorData(err)
+	}
+
+	// Assert
+	asserts.Nil(errs[0])
+	asserts.ErrorIs(errs[1], main.ErrRateLimitBudget)
+	statuses := appAuth.Status()
+	uts.Require().Len(statuses, 1)
+	asserts.Equal(appAuth.Identity(), statuses[0].Identity)
+	asserts.True(statuses[0].BudgetKnown)
+	asserts.Equal(50, statuses[0].Budget.Remaining)
+	asserts.Equal(1, statuses[0].Budget.Cost, "the cost is only in the injected field")
+	asserts.Contains(scrape(uts.T(), addr.String()), `readme_studio_rate_limit_remaining{token="`+appAuth.Identity()+`"} 50`)
+}
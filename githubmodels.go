@@ -1,12 +1,33 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+// The models, their variables and queries are generated from the
+// operations in queries/ by cmd/graphqlgen, into githubmodels_gen.go.
+//go:generate go run ./cmd/graphqlgen -schema schema/github.graphql -package main -out githubmodels_gen.go queries
+
+// GithubFragments holds the fragments models share, see queries/. Clients
+// add the ones a query spreads to it before sending it.
+var GithubFragments = graphql.MustFragmentRegistry(generatedFragments...)
 
 type GithubResultModel[data any] struct {
 	Data   data               `json:"data"`
 	Errors []GithubErrorModel `json:"errors"`
 }
 
+// GithubModelCacheTTLs tells for how long a cached answer to each model's
+// query stays fresh, by operation name.
+var GithubModelCacheTTLs = map[string]time.Duration{
+	"GithubRepositoryCard":      10 * time.Minute,
+	"GithubRepositoryCardBatch": 10 * time.Minute,
+	"GithubUserRepositories":    30 * time.Minute,
+}
+
 type GithubModel interface {
 	makeQuery(any) string
 }
@@ -19,7 +40,7 @@ type GithubModel interface {
 // }
 
 type GithubErrorModel struct {
-	Path       []string `json:"path"`
+	Path       []any `json:"path"`
 	Extensions struct {
 		Code      string `json:"code"`
 		TypeName  string `json:"typeName"`
@@ -29,48 +50,22 @@ type GithubErrorModel struct {
 		Line   int `json:"line"`
 		Column int `json:"column"`
 	} `json:"locations"`
+	Type    string `json:"type"`
 	Message string `json:"message"`
 }
 
-type GithubRepositoryCardModel struct {
-	Repository struct {
-		Name        string `json:"name"`
-		IsArchived  bool   `json:"isArchived"`
-		Description string `json:"description"`
-		Parent      struct {
-			NameWithOwner string `json:"nameWithOwner"`
-		} `json:"parent"`
-		Languages struct {
-			Nodes []struct {
-				Name  string `json:"name"`
-				Color string `json:"color"`
-			} `json:"nodes"`
-		} `json:"languages"`
-		StargazerCount int `json:"stargazerCount"`
-		ForkCount      int `json:"forkCount"`
-	} `json:"repository"`
+func (*GithubRepositoryCardModel) makeQuery(name string, owner string) (string, GithubRepositoryCardVariables) {
+	return githubRepositoryCardQuery, GithubRepositoryCardVariables{
+		Name:  name,
+		Owner: owner,
+	}
 }
 
-func (*GithubRepositoryCardModel) makeQuery(name string, owner string) string {
-	return fmt.Sprintf(
-		`{
-			repository(name: "%s", owner: "%s") {
-				name
-				isArchived
-				description
-				parent {
-					nameWithOwner
-				}
-				languages(first: 1, orderBy: {field: SIZE, direction: DESC}) {
-					nodes {
-						name
-						color
-					}
-				}
-				stargazerCount
-				forkCount
-			}
-			}`, name, owner)
+func FetchRepositoryCard(ctx context.Context, client *GraphQlClient, name string, owner string) (GithubResultModel[GithubRepositoryCardModel], error) {
+	var queryResult GithubResultModel[GithubRepositoryCardModel]
+	query, variables := queryResult.Data.makeQuery(name, owner)
+	err := client.Do(ctx, query, variables, &queryResult)
+	return queryResult, err
 }
 
 // func (*GithubRepositoryCardModel) resultStruct() GithubResultModel[GithubRepositoryCardModel] {
@@ -84,3 +79,31 @@ func (*GithubRepositoryCardModel) makeQuery(name string, owner string) string {
 // func (*GithubModel) resultStruct() GithubResultModel[GithubModel] {
 // 	return GithubResultModel[GithubModel]{}
 // }
+
+func (*GithubUserRepositoriesModel) makeQuery(login string) (string, func(first int, after *string) any) {
+	return githubUserRepositoriesQuery, func(first int, after *string) any {
+		return GithubUserRepositoriesVariables{
+			Login: login,
+			First: first,
+			After: after,
+		}
+	}
+}
+
+func (m *GithubUserRepositoriesModel) connection() (GithubPageInfoModel, int) {
+	return GithubPageInfoModel(m.User.Repositories.PageInfo), len(m.User.Repositories.Nodes)
+}
+
+// NewUserRepositoriesPaginator walks every repository owned by login,
+// stopping after maxItems of them unless maxItems is zero.
+func NewUserRepositoriesPaginator(client *GraphQlClient, login string, maxItems int) *Paginator[GithubUserRepositoriesModel] {
+	var model GithubUserRepositoriesModel
+	query, variables := model.makeQuery(login)
+	return &Paginator[GithubUserRepositoriesModel]{
+		Client:     client,
+		Query:      query,
+		Variables:  variables,
+		Connection: (*GithubUserRepositoriesModel).connection,
+		MaxItems:   maxItems,
+	}
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/joho/godotenv v1.4.0
 	github.com/stretchr/testify v1.8.1
+	golang.org/x/net v0.17.0
 )
 
 require (
@@ -12,5 +13,6 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.0 // indirect
 	golang.org/x/exp v0.0.0-20230111222715-75897c7a292a
+	golang.org/x/text v0.13.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
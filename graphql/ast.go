@@ -0,0 +1,230 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Document is a parsed executable document: the operations and fragments
+// a client sends.
+type Document struct {
+	Operations []*Operation
+	Fragments  []*Fragment
+}
+
+// Operation returns the operation called name, or the only operation of
+// the document when name is empty.
+func (d *Document) Operation(name string) *Operation {
+	if name == "" {
+		if len(d.Operations) == 1 {
+			return d.Operations[0]
+		}
+		return nil
+	}
+	for _, operation := range d.Operations {
+		if operation.Name == name {
+			return operation
+		}
+	}
+	return nil
+}
+
+func (d *Document) Fragment(name string) *Fragment {
+	for _, fragment := range d.Fragments {
+		if fragment.Name == name {
+			return fragment
+		}
+	}
+	return nil
+}
+
+type OperationKind string
+
+const (
+	OperationQuery        OperationKind = "query"
+	OperationMutation     OperationKind = "mutation"
+	OperationSubscription OperationKind = "subscription"
+)
+
+type Operation struct {
+	Kind                OperationKind
+	Name                string
+	VariableDefinitions []*VariableDefinition
+	Directives          []*Directive
+	SelectionSet        SelectionSet
+	Pos                 Position
+}
+
+type VariableDefinition struct {
+	Name    string
+	Type    *Type
+	Default *Value
+	Pos     Position
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  SelectionSet
+	Pos           Position
+}
+
+// Type is a type reference such as `[String!]!`. Exactly one of Name and
+// Elem is set.
+type Type struct {
+	Name    string
+	Elem    *Type
+	NonNull bool
+}
+
+// NamedType is the innermost type name of t, without lists or non-null.
+func (t *Type) NamedType() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+func (t *Type) String() string {
+	var s string
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	} else {
+		s = t.Name
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Selection is one of *Field, *FragmentSpread and *InlineFragment.
+type Selection interface {
+	Position() Position
+}
+
+type SelectionSet []Selection
+
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet SelectionSet
+	Pos          Position
+}
+
+// ResponseKey is the key the field's value has in the response.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+func (f *Field) Argument(name string) *Argument {
+	for _, argument := range f.Arguments {
+		if argument.Name == name {
+			return argument
+		}
+	}
+	return nil
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Pos        Position
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  SelectionSet
+	Pos           Position
+}
+
+func (f *Field) Position() Position          { return f.Pos }
+func (f *FragmentSpread) Position() Position { return f.Pos }
+func (f *InlineFragment) Position() Position { return f.Pos }
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Pos       Position
+}
+
+type Argument struct {
+	Name  string
+	Value *Value
+	Pos   Position
+}
+
+type ValueKind int
+
+const (
+	ValueVariable ValueKind = iota
+	ValueInt
+	ValueFloat
+	ValueString
+	ValueBoolean
+	ValueNull
+	ValueEnum
+	ValueList
+	ValueObject
+)
+
+// Value is an argument or default value. Raw holds the variable name,
+// the literal text or the enum name, List and Fields the contents of lists
+// and input objects.
+type Value struct {
+	Kind   ValueKind
+	Raw    string
+	List   []*Value
+	Fields []*ObjectField
+	Pos    Position
+}
+
+type ObjectField struct {
+	Name  string
+	Value *Value
+}
+
+// Resolve turns the value into the Go value encoding/json would produce for
+// it, looking variables up in variables. Enums resolve to their name.
+func (v *Value) Resolve(variables map[string]any) (any, error) {
+	switch v.Kind {
+	case ValueVariable:
+		return variables[v.Raw], nil
+	case ValueInt, ValueFloat:
+		return strconv.ParseFloat(v.Raw, 64)
+	case ValueString, ValueEnum:
+		return v.Raw, nil
+	case ValueBoolean:
+		return v.Raw == "true", nil
+	case ValueNull:
+		return nil, nil
+	case ValueList:
+		list := make([]any, len(v.List))
+		for i, item := range v.List {
+			resolved, err := item.Resolve(variables)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	case ValueObject:
+		object := make(map[string]any, len(v.Fields))
+		for _, field := range v.Fields {
+			resolved, err := field.Value.Resolve(variables)
+			if err != nil {
+				return nil, err
+			}
+			object[field.Name] = resolved
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("graphql: unknown value kind %d", v.Kind)
+}
@@ -0,0 +1,474 @@
+package graphql
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Source is a document to generate Go code for, Name being where it was
+// read from.
+type Source struct {
+	Name string
+	Body string
+}
+
+// ReadSources reads the .graphql files among paths, and the ones in the
+// directories among them, in the order of their names.
+func ReadSources(paths ...string) ([]Source, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.graphql"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	sources := make([]Source, len(files))
+	for i, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = Source{Name: filepath.ToSlash(file), Body: string(body)}
+	}
+	return sources, nil
+}
+
+// GoScalars maps scalars to Go types for Generate. Scalars it doesn't list
+// become strings, which is how GitHub serializes every custom scalar.
+var GoScalars = map[string]string{
+	"Int":      "int",
+	"Float":    "float64",
+	"String":   "string",
+	"ID":       "string",
+	"Boolean":  "bool",
+	"DateTime": "time.Time",
+}
+
+// goInitialisms are written in upper case in Go names, as in NameWithOwner
+// but AvatarURL.
+var goInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "JSON": true,
+	"SQL": true, "URI": true, "URL": true,
+}
+
+// Generate turns sources into Go code for package pkg. A source holds
+// either one operation, and the fragments only it spreads, or one fragment
+// shared by every operation. An operation Name yields:
+//
+//   - NameModel, the type of the data of its response, with nested
+//     selections as nested structs. A null decodes to the zero value.
+//   - NameVariables, with a field per variable, nullable ones as pointers.
+//   - nameQuery, the operation's document as written, to be completed
+//     with the shared fragments it spreads by a FragmentRegistry.
+//
+// A fragment Name yields the struct NameFragment, which the structs of
+// the selections spreading it embed, and shared fragments the constant
+// nameFragment, listed in generatedFragments for registering them. Input
+// objects that variables use become structs of their own.
+func Generate(schema *Schema, pkg string, sources []Source) ([]byte, error) {
+	g := &generator{schema: schema, inputs: map[string]bool{}, imports: map[string]bool{}}
+
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Name
+	}
+	fmt.Fprintf(&g.body, "// Code generated by graphqlgen from %s. DO NOT EDIT.\n\n", strings.Join(names, ", "))
+	fmt.Fprintf(&g.body, "package %s\n\n", pkg)
+	header := g.body.Len()
+
+	// Shared fragments are registered first, so operations can spread
+	// them whatever order the sources come in.
+	registry := NewFragmentRegistry()
+	documents := make([]*Document, len(sources))
+	definedIn := map[string]string{}
+	var shared []string
+	for i, source := range sources {
+		document, err := Parse(source.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Name, err)
+		}
+		documents[i] = document
+		switch {
+		case len(document.Operations) == 0 && len(document.Fragments) != 1,
+			len(document.Operations) > 1,
+			len(document.Operations) == 1 && document.Operations[0].Name == "":
+			return nil, fmt.Errorf("%s: graphql: need exactly one named operation, or one fragment, to generate code for", source.Name)
+		case len(document.Operations) == 1:
+			name := document.Operations[0].Name
+			if previous, ok := definedIn[name]; ok {
+				return nil, fmt.Errorf("%s: graphql: %s is already defined in %s", source.Name, name, previous)
+			}
+			definedIn[name] = source.Name
+		}
+		for _, fragment := range document.Fragments {
+			if previous, ok := definedIn[fragment.Name+"Fragment"]; ok {
+				return nil, fmt.Errorf("%s: graphql: fragment %s is already defined in %s", source.Name, fragment.Name, previous)
+			}
+			definedIn[fragment.Name+"Fragment"] = source.Name
+		}
+		if len(document.Operations) == 0 {
+			if err := registry.Register(source.Body); err != nil {
+				return nil, fmt.Errorf("%s: %w", source.Name, err)
+			}
+			shared = append(shared, document.Fragments[0].Name)
+		}
+	}
+
+	for i, source := range sources {
+		completed, err := registry.Complete(source.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Name, err)
+		}
+		document, err := Parse(completed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Name, err)
+		}
+		if errs := Validate(schema, document); len(errs) > 0 {
+			return nil, fmt.Errorf("%s: %w", source.Name, errs[0])
+		}
+		for _, operation := range document.Operations {
+			g.operation(document, operation, source.Body)
+		}
+		for _, fragment := range documents[i].Fragments {
+			g.fragment(document, document.Fragment(fragment.Name), source.Body, len(documents[i].Operations) == 0)
+		}
+		if g.err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Name, g.err)
+		}
+	}
+
+	g.body.WriteString("// generatedFragments are the definitions of the fragments shared between\n" +
+		"// operations, for a FragmentRegistry.\n")
+	g.body.WriteString("var generatedFragments = []string{\n")
+	for _, name := range shared {
+		fmt.Fprintf(&g.body, "%sFragment,\n", lowerFirst(name))
+	}
+	g.body.WriteString("}\n\n")
+	g.inputObjects()
+
+	var imports strings.Builder
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		imports.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&imports, "\t%q\n", path)
+		}
+		imports.WriteString(")\n\n")
+	}
+	code := g.body.String()
+	code = code[:header] + imports.String() + code[header:]
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return nil, fmt.Errorf("graphql: generated invalid Go code: %w", err)
+	}
+	return formatted, nil
+}
+
+type generator struct {
+	schema  *Schema
+	body    strings.Builder
+	inputs  map[string]bool
+	imports map[string]bool
+	err     error
+}
+
+func (g *generator) failf(format string, args ...any) {
+	if g.err == nil {
+		g.err = fmt.Errorf("graphql: "+format, args...)
+	}
+}
+
+func (g *generator) operation(document *Document, operation *Operation, source string) {
+	root := g.schema.RootType(operation.Kind)
+
+	fmt.Fprintf(&g.body, "type %sModel ", operation.Name)
+	g.selectionStruct(document, root, []SelectionSet{operation.SelectionSet}, "")
+	g.body.WriteString("\n\n")
+
+	fmt.Fprintf(&g.body, "type %sVariables struct {\n", operation.Name)
+	for _, variable := range operation.VariableDefinitions {
+		goType := g.inputType(variable.Type)
+		if !variable.Type.NonNull {
+			goType = "*" + goType
+		}
+		fmt.Fprintf(&g.body, "%s %s `json:%q`\n", goName(variable.Name), goType, variable.Name)
+	}
+	g.body.WriteString("}\n\n")
+
+	fmt.Fprintf(&g.body, "const %sQuery = %s\n\n", lowerFirst(operation.Name), goString(documentText(source)))
+}
+
+func (g *generator) fragment(document *Document, fragment *Fragment, source string, shared bool) {
+	fmt.Fprintf(&g.body, "type %sFragment ", fragment.Name)
+	g.selectionStruct(document, g.schema.Types[fragment.TypeCondition], []SelectionSet{fragment.SelectionSet}, "")
+	g.body.WriteString("\n\n")
+	if shared {
+		fmt.Fprintf(&g.body, "const %sFragment = %s\n\n", lowerFirst(fragment.Name), goString(documentText(source)))
+	}
+}
+
+// documentText is source without the comments and blank lines heading it.
+func documentText(source string) string {
+	lines := strings.Split(strings.TrimSpace(source), "\n")
+	for len(lines) > 0 && (strings.HasPrefix(strings.TrimSpace(lines[0]), "#") || strings.TrimSpace(lines[0]) == "") {
+		lines = lines[1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goString quotes s as a raw string literal where it can.
+func goString(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// responseField is every selection of one response key, merged.
+type responseField struct {
+	key        string
+	definition *FieldDefinition
+	selections []SelectionSet
+}
+
+// collectFields merges the fields of sets by response key, flattening
+// inline fragments into the fields they hold. Fragment spreads are
+// returned apart, in the order they are first spread.
+func (g *generator) collectFields(document *Document, parent *TypeDefinition, sets []SelectionSet) ([]*responseField, []string) {
+	var fields []*responseField
+	var spreads []string
+	byKey := map[string]*responseField{}
+	spread := map[string]bool{}
+	var collect func(parent *TypeDefinition, set SelectionSet)
+	collect = func(parent *TypeDefinition, set SelectionSet) {
+		for _, selection := range set {
+			switch selection := selection.(type) {
+			case *Field:
+				field := byKey[selection.ResponseKey()]
+				if field == nil {
+					field = &responseField{key: selection.ResponseKey(), definition: g.fieldDefinition(parent, selection.Name)}
+					byKey[field.key] = field
+					fields = append(fields, field)
+				}
+				if len(selection.SelectionSet) > 0 {
+					field.selections = append(field.selections, selection.SelectionSet)
+				}
+			case *InlineFragment:
+				condition := parent
+				if selection.TypeCondition != "" {
+					condition = g.schema.Types[selection.TypeCondition]
+				}
+				collect(condition, selection.SelectionSet)
+			case *FragmentSpread:
+				if !spread[selection.Name] {
+					spread[selection.Name] = true
+					spreads = append(spreads, selection.Name)
+				}
+			}
+		}
+	}
+	for _, set := range sets {
+		collect(parent, set)
+	}
+	return fields, spreads
+}
+
+// fragmentKeys adds the response keys the struct of fragment name gets,
+// its embedded fragments' included, to keys.
+func fragmentKeys(document *Document, name string, keys map[string]bool) {
+	var collect func(set SelectionSet)
+	collect = func(set SelectionSet) {
+		for _, selection := range set {
+			switch selection := selection.(type) {
+			case *Field:
+				keys[selection.ResponseKey()] = true
+			case *InlineFragment:
+				collect(selection.SelectionSet)
+			case *FragmentSpread:
+				fragmentKeys(document, selection.Name, keys)
+			}
+		}
+	}
+	collect(document.Fragment(name).SelectionSet)
+}
+
+func (g *generator) fieldDefinition(parent *TypeDefinition, name string) *FieldDefinition {
+	if name == "__typename" {
+		return &FieldDefinition{Name: name, Type: &Type{Name: "String", NonNull: true}}
+	}
+	return parent.Field(name)
+}
+
+// selectionStruct writes the struct sets decode into. Fragments they spread
+// are embedded, which encoding/json only fills as long as no two of them
+// hold the same key, so that is refused. Leaf fields selected next to a
+// fragment holding them come from the fragment.
+func (g *generator) selectionStruct(document *Document, parent *TypeDefinition, sets []SelectionSet, indent string) {
+	fields, spreads := g.collectFields(document, parent, sets)
+
+	embedded := map[string]string{}
+	for _, name := range spreads {
+		keys := map[string]bool{}
+		fragmentKeys(document, name, keys)
+		for key := range keys {
+			if other, ok := embedded[key]; ok {
+				g.failf("fragments %s and %s both select %q next to each other, which their embedded structs can't decode", other, name, key)
+			}
+			embedded[key] = name
+		}
+	}
+
+	g.body.WriteString("struct {\n")
+	for _, name := range spreads {
+		fmt.Fprintf(&g.body, "%s\t%sFragment\n", indent, name)
+	}
+	for _, field := range fields {
+		if fragment, ok := embedded[field.key]; ok {
+			if len(field.selections) > 0 {
+				g.failf("%q is selected both directly and through fragment %s, select it in one place", field.key, fragment)
+			}
+			continue
+		}
+		fmt.Fprintf(&g.body, "%s\t%s ", indent, goName(field.key))
+		g.outputType(document, field, field.definition.Type, indent+"\t")
+		fmt.Fprintf(&g.body, " `json:%q`\n", field.key)
+	}
+	g.body.WriteString(indent + "}")
+}
+
+func (g *generator) outputType(document *Document, field *responseField, t *Type, indent string) {
+	if t.Elem != nil {
+		g.body.WriteString("[]")
+		g.outputType(document, field, t.Elem, indent)
+		return
+	}
+	named := g.schema.Types[t.Name]
+	if named.IsComposite() {
+		g.selectionStruct(document, named, field.selections, indent)
+		return
+	}
+	g.body.WriteString(g.scalarType(named))
+}
+
+func (g *generator) scalarType(named *TypeDefinition) string {
+	goType, ok := GoScalars[named.Name]
+	if !ok || named.Kind == KindEnum {
+		return "string"
+	}
+	if i := strings.LastIndex(goType, "."); i >= 0 {
+		g.imports[scalarImports[goType[:i]]] = true
+	}
+	return goType
+}
+
+// scalarImports maps the package names GoScalars use to import paths.
+var scalarImports = map[string]string{
+	"time": "time",
+	"json": "encoding/json",
+}
+
+func (g *generator) inputType(t *Type) string {
+	if t.Elem != nil {
+		elem := g.inputType(t.Elem)
+		if !t.Elem.NonNull && g.schema.Types[t.Elem.NamedType()].Kind == KindInputObject {
+			elem = "*" + elem
+		}
+		return "[]" + elem
+	}
+	named := g.schema.Types[t.Name]
+	if named.Kind == KindInputObject {
+		g.inputs[named.Name] = true
+		return named.Name
+	}
+	return g.scalarType(named)
+}
+
+// inputObjects writes a struct for every input object variables use,
+// including the ones those refer to.
+func (g *generator) inputObjects() {
+	written := map[string]bool{}
+	for {
+		var pending []string
+		for name := range g.inputs {
+			if !written[name] {
+				pending = append(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+		sort.Strings(pending)
+		for _, name := range pending {
+			written[name] = true
+			definition := g.schema.Types[name]
+			if definition.Description != "" {
+				fmt.Fprintf(&g.body, "// %s is %s\n", name, goComment(definition.Description))
+			}
+			fmt.Fprintf(&g.body, "type %s struct {\n", name)
+			for _, field := range definition.InputFields {
+				goType := g.inputType(field.Type)
+				tag := field.Name
+				if !field.Type.NonNull {
+					goType = "*" + goType
+					tag += ",omitempty"
+				}
+				fmt.Fprintf(&g.body, "%s %s `json:%q`\n", goName(field.Name), goType, tag)
+			}
+			g.body.WriteString("}\n\n")
+		}
+	}
+}
+
+func goComment(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	return strings.ToLower(description[:1]) + description[1:]
+}
+
+// goName turns a GraphQL name into an exported Go one, minding Go's
+// initialisms.
+func goName(name string) string {
+	var words []string
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		if i == len(name) || name[i] == '_' || unicode.IsUpper(rune(name[i])) && !unicode.IsUpper(rune(name[i-1])) {
+			if word := strings.Trim(name[start:i], "_"); word != "" {
+				words = append(words, word)
+			}
+			start = i
+		}
+	}
+	var goName strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			goName.WriteString(upper)
+			continue
+		}
+		goName.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return goName.String()
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
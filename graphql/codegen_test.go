@@ -0,0 +1,213 @@
+package graphql_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestCodegenSuite struct {
+	suite.Suite
+	schema *graphql.Schema
+}
+
+func TestUnitTestCodegenSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestCodegenSuite))
+}
+
+func (uts *UnitTestCodegenSuite) SetupSuite() {
+	schema, err := graphql.ParseSchema(testSchema)
+	uts.Require().NoError(err)
+	uts.schema = schema
+}
+
+const ownerRepositoriesSource = `# Repositories of an owner.
+query OwnerRepositories($login: String!, $first: Int, $order: RepositoryOrder) {
+	owner(login: $login) {
+		__typename
+		repositories(first: $first, orderBy: $order) {
+			totalCount
+			nodes { ...Repository }
+		}
+		... on User { login }
+	}
+}
+
+fragment Repository on Repository {
+	...RepositorySummary
+	name
+	languageCount: languages { nodes { name } }
+}`
+
+const repositorySummarySource = `fragment RepositorySummary on Repository {
+	name
+	createdAt
+}`
+
+func (uts *UnitTestCodegenSuite) Test_Generate() {
+	// Arrange
+	sources := []graphql.Source{
+		{Name: "OwnerRepositories.graphql", Body: ownerRepositoriesSource},
+		{Name: "RepositorySummary.graphql", Body: repositorySummarySource},
+	}
+
+	// Act
+	code, err := graphql.Generate(uts.schema, "models", sources)
+
+	// Assert
+	uts.Require().NoError(err)
+	uts.Equal("// Code generated by graphqlgen from OwnerRepositories.graphql, RepositorySummary.graphql. DO NOT EDIT.\n"+
+		"\n"+
+		"package models\n"+
+		"\n"+
+		"import (\n"+
+		"\t\"time\"\n"+
+		")\n"+
+		"\n"+
+		"type OwnerRepositoriesModel struct {\n"+
+		"\tOwner struct {\n"+
+		"\t\tTypename     string `json:\"__typename\"`\n"+
+		"\t\tRepositories struct {\n"+
+		"\t\t\tTotalCount int `json:\"totalCount\"`\n"+
+		"\t\t\tNodes      []struct {\n"+
+		"\t\t\t\tRepositoryFragment\n"+
+		"\t\t\t} `json:\"nodes\"`\n"+
+		"\t\t} `json:\"repositories\"`\n"+
+		"\t\tLogin string `json:\"login\"`\n"+
+		"\t} `json:\"owner\"`\n"+
+		"}\n"+
+		"\n"+
+		"type OwnerRepositoriesVariables struct {\n"+
+		"\tLogin string           `json:\"login\"`\n"+
+		"\tFirst *int             `json:\"first\"`\n"+
+		"\tOrder *RepositoryOrder `json:\"order\"`\n"+
+		"}\n"+
+		"\n"+
+		"const ownerRepositoriesQuery = `query OwnerRepositories($login: String!, $first: Int, $order: RepositoryOrder) {\n"+
+		"\towner(login: $login) {\n"+
+		"\t\t__typename\n"+
+		"\t\trepositories(first: $first, orderBy: $order) {\n"+
+		"\t\t\ttotalCount\n"+
+		"\t\t\tnodes { ...Repository }\n"+
+		"\t\t}\n"+
+		"\t\t... on User { login }\n"+
+		"\t}\n"+
+		"}\n"+
+		"\n"+
+		"fragment Repository on Repository {\n"+
+		"\t...RepositorySummary\n"+
+		"\tname\n"+
+		"\tlanguageCount: languages { nodes { name } }\n"+
+		"}`\n"+
+		"\n"+
+		"type RepositoryFragment struct {\n"+
+		"\tRepositorySummaryFragment\n"+
+		"\tLanguageCount struct {\n"+
+		"\t\tNodes []struct {\n"+
+		"\t\t\tName string `json:\"name\"`\n"+
+		"\t\t} `json:\"nodes\"`\n"+
+		"\t} `json:\"languageCount\"`\n"+
+		"}\n"+
+		"\n"+
+		"type RepositorySummaryFragment struct {\n"+
+		"\tName      string    `json:\"name\"`\n"+
+		"\tCreatedAt time.Time `json:\"createdAt\"`\n"+
+		"}\n"+
+		"\n"+
+		"const repositorySummaryFragment = `fragment RepositorySummary on Repository {\n"+
+		"\tname\n"+
+		"\tcreatedAt\n"+
+		"}`\n"+
+		"\n"+
+		"// generatedFragments are the definitions of the fragments shared between\n"+
+		"// operations, for a FragmentRegistry.\n"+
+		"var generatedFragments = []string{\n"+
+		"\trepositorySummaryFragment,\n"+
+		"}\n"+
+		"\n"+
+		"type RepositoryOrder struct {\n"+
+		"\tField     string  `json:\"field\"`\n"+
+		"\tDirection *string `json:\"direction,omitempty\"`\n"+
+		"}\n", string(code))
+}
+
+func (uts *UnitTestCodegenSuite) Test_GenerateErrors() {
+	tests := []struct {
+		name    string
+		sources []graphql.Source
+	}{
+		{
+			name:    "invalid query",
+			sources: []graphql.Source{{Name: "a.graphql", Body: `query A { owner(login: "a") { nmae } }`}},
+		},
+		{
+			name:    "anonymous operation",
+			sources: []graphql.Source{{Name: "a.graphql", Body: `{ owner(login: "a") { login } }`}},
+		},
+		{
+			name: "two operations in one file",
+			sources: []graphql.Source{{Name: "a.graphql", Body: `query A { owner(login: "a") { login } }
+				query B { owner(login: "b") { login } }`}},
+		},
+		{
+			name:    "undefined fragment",
+			sources: []graphql.Source{{Name: "a.graphql", Body: `query A { owner(login: "a") { ...Missing } }`}},
+		},
+		{
+			name:    "two fragments in a shared file",
+			sources: []graphql.Source{{Name: "a.graphql", Body: `fragment A on Owner { login } fragment B on Owner { login }`}},
+		},
+		{
+			name: "fragments selecting the same key side by side",
+			sources: []graphql.Source{
+				{Name: "a.graphql", Body: `query A { repository(name: "a", owner: "b") { ...Name ...Summary } }`},
+				{Name: "name.graphql", Body: `fragment Name on Repository { name }`},
+				{Name: "summary.graphql", Body: repositorySummarySource},
+			},
+		},
+		{
+			name: "object selected directly and through a fragment",
+			sources: []graphql.Source{
+				{Name: "a.graphql", Body: `query A { repository(name: "a", owner: "b") { ...Owner owner { __typename } } }`},
+				{Name: "owner.graphql", Body: `fragment Owner on Repository { owner { login } }`},
+			},
+		},
+		{
+			name: "operation defined twice",
+			sources: []graphql.Source{
+				{Name: "a.graphql", Body: `query A { owner(login: "a") { login } }`},
+				{Name: "b.graphql", Body: `query A { owner(login: "b") { login } }`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Act
+			_, err := graphql.Generate(uts.schema, "models", tt.sources)
+
+			// Assert
+			uts.Error(err)
+		})
+	}
+}
+
+func (uts *UnitTestCodegenSuite) Test_ReadSources() {
+	// Arrange
+	dir := uts.T().TempDir()
+	uts.Require().NoError(os.WriteFile(filepath.Join(dir, "b.graphql"), []byte("query B { b }"), 0o644))
+	uts.Require().NoError(os.WriteFile(filepath.Join(dir, "a.graphql"), []byte("query A { a }"), 0o644))
+	uts.Require().NoError(os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a query"), 0o644))
+
+	// Act
+	sources, err := graphql.ReadSources(dir)
+
+	// Assert
+	uts.Require().NoError(err)
+	uts.Require().Len(sources, 2)
+	uts.Equal("query A { a }", sources[0].Body)
+	uts.Equal("query B { b }", sources[1].Body)
+}
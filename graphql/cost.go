@@ -0,0 +1,100 @@
+package graphql
+
+import "fmt"
+
+// GitHub refuses queries that could return more nodes than MaxNodes and
+// connections asking for more than MaxConnectionSize items at once.
+const (
+	MaxNodes          = 500000
+	MaxConnectionSize = 100
+)
+
+// Cost is what a query is expected to cost against GitHub's limits,
+// estimated the way https://docs.github.com/en/graphql/overview/rate-limits-and-node-limits
+// describes it.
+type Cost struct {
+	// Nodes is the most nodes the query can return.
+	Nodes int
+	// Requests is how many requests GitHub counts for fulfilling every
+	// connection of the query.
+	Requests int
+	// Points is what the query takes off the hourly rate limit.
+	Points int
+}
+
+// EstimateCost estimates the cost of the operation called operationName,
+// which may be empty for documents holding only one. A connection's size
+// is its first or last argument, looked up in variables when it's a
+// variable. Sizes that can't be known count as MaxConnectionSize.
+func EstimateCost(document *Document, operationName string, variables map[string]any) (Cost, error) {
+	operation := document.Operation(operationName)
+	if operation == nil {
+		return Cost{}, fmt.Errorf("graphql: no operation %q in document", operationName)
+	}
+	var cost Cost
+	estimator := &costEstimator{document: document, variables: variables, spreading: map[string]bool{}}
+	estimator.selectionSet(operation.SelectionSet, 1, &cost)
+	// Points are requests per hundred rounded to the nearest whole number,
+	// but never less than one.
+	cost.Points = (cost.Requests + 50) / 100
+	if cost.Points < 1 {
+		cost.Points = 1
+	}
+	return cost, nil
+}
+
+type costEstimator struct {
+	document  *Document
+	variables map[string]any
+	spreading map[string]bool
+}
+
+// selectionSet adds the cost of set to cost, multiplier being how many
+// times set is resolved because of the connections it is nested in.
+func (e *costEstimator) selectionSet(set SelectionSet, multiplier int, cost *Cost) {
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *Field:
+			childMultiplier := multiplier
+			if size, ok := e.connectionSize(selection); ok {
+				cost.Requests += multiplier
+				childMultiplier = multiplier * size
+				cost.Nodes += childMultiplier
+			}
+			e.selectionSet(selection.SelectionSet, childMultiplier, cost)
+		case *InlineFragment:
+			e.selectionSet(selection.SelectionSet, multiplier, cost)
+		case *FragmentSpread:
+			fragment := e.document.Fragment(selection.Name)
+			if fragment == nil || e.spreading[fragment.Name] {
+				continue
+			}
+			e.spreading[fragment.Name] = true
+			e.selectionSet(fragment.SelectionSet, multiplier, cost)
+			delete(e.spreading, fragment.Name)
+		}
+	}
+}
+
+// connectionSize returns the page size a field asks a connection for, with
+// false for fields that aren't connections.
+func (e *costEstimator) connectionSize(field *Field) (int, bool) {
+	argument := field.Argument("first")
+	if argument == nil {
+		argument = field.Argument("last")
+	}
+	if argument == nil {
+		return 0, false
+	}
+	resolved, err := argument.Value.Resolve(e.variables)
+	if err != nil {
+		return MaxConnectionSize, true
+	}
+	switch size := resolved.(type) {
+	case float64:
+		return int(size), true
+	case int:
+		return size, true
+	}
+	return MaxConnectionSize, true
+}
@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FragmentRegistry holds fragments shared between documents, so that a
+// document can spread them without defining them itself.
+type FragmentRegistry struct {
+	mu          sync.RWMutex
+	definitions map[string]string
+	fragments   map[string]*Fragment
+}
+
+func NewFragmentRegistry() *FragmentRegistry {
+	return &FragmentRegistry{
+		definitions: map[string]string{},
+		fragments:   map[string]*Fragment{},
+	}
+}
+
+// MustFragmentRegistry returns a registry holding definitions and panics
+// when one of them can't be registered. It is meant for fragments known at
+// compile time.
+func MustFragmentRegistry(definitions ...string) *FragmentRegistry {
+	registry := NewFragmentRegistry()
+	for _, definition := range definitions {
+		if err := registry.Register(definition); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// Register adds definition, a document holding exactly one fragment, to
+// the registry. Fragments can't be registered twice under the same name.
+func (r *FragmentRegistry) Register(definition string) error {
+	document, err := Parse(definition)
+	if err != nil {
+		return err
+	}
+	if len(document.Operations) > 0 || len(document.Fragments) != 1 {
+		return fmt.Errorf("graphql: a registered fragment must be a document holding just that fragment")
+	}
+	fragment := document.Fragments[0]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.fragments[fragment.Name]; ok {
+		return fmt.Errorf("graphql: fragment %s is already registered", fragment.Name)
+	}
+	r.definitions[fragment.Name] = strings.TrimSpace(definition)
+	r.fragments[fragment.Name] = fragment
+	return nil
+}
+
+// Fragment returns the registered fragment called name, or nil.
+func (r *FragmentRegistry) Fragment(name string) *Fragment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.fragments[name]
+}
+
+// Complete appends to document the definitions of the registered
+// fragments it spreads without defining them, directly or through other
+// fragments, in the order they are first spread. Documents that need no
+// fragment are returned as they are.
+func (r *FragmentRegistry) Complete(document string) (string, error) {
+	if !strings.Contains(document, "...") {
+		return document, nil
+	}
+	parsed, err := Parse(document)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var missing []string
+	visited := map[string]bool{}
+	var visit func(set SelectionSet) error
+	visit = func(set SelectionSet) error {
+		for _, selection := range set {
+			var err error
+			switch selection := selection.(type) {
+			case *Field:
+				err = visit(selection.SelectionSet)
+			case *InlineFragment:
+				err = visit(selection.SelectionSet)
+			case *FragmentSpread:
+				if visited[selection.Name] {
+					continue
+				}
+				visited[selection.Name] = true
+				fragment := parsed.Fragment(selection.Name)
+				if fragment == nil {
+					if fragment = r.fragments[selection.Name]; fragment == nil {
+						return &ValidationError{
+							Message: fmt.Sprintf("Fragment %s was used, but neither defined nor registered", selection.Name),
+							Pos:     selection.Pos,
+						}
+					}
+					missing = append(missing, selection.Name)
+				}
+				err = visit(fragment.SelectionSet)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, operation := range parsed.Operations {
+		if err := visit(operation.SelectionSet); err != nil {
+			return "", err
+		}
+	}
+	for _, fragment := range parsed.Fragments {
+		if err := visit(fragment.SelectionSet); err != nil {
+			return "", err
+		}
+	}
+
+	if len(missing) == 0 {
+		return document, nil
+	}
+	var completed strings.Builder
+	completed.WriteString(document)
+	for _, name := range missing {
+		completed.WriteString("\n\n")
+		completed.WriteString(r.definitions[name])
+	}
+	return completed.String(), nil
+}
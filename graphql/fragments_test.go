@@ -0,0 +1,128 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestFragmentRegistrySuite struct {
+	suite.Suite
+}
+
+func TestUnitTestFragmentRegistrySuite(t *testing.T) {
+	suite.Run(t, new(UnitTestFragmentRegistrySuite))
+}
+
+func (uts *UnitTestFragmentRegistrySuite) Test_Complete() {
+	registry := graphql.MustFragmentRegistry(
+		"fragment Card on Repository { ...Summary isArchived }",
+		"fragment Summary on Repository { name ...Language }\n",
+		"fragment Language on Repository { primaryLanguage { name } }",
+		"fragment Unused on Repository { forkCount }",
+	)
+	tests := []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{
+			name:     "no spreads",
+			document: `{ repository(name: "a", owner: "b") { name } }`,
+			expected: `{ repository(name: "a", owner: "b") { name } }`,
+		},
+		{
+			name:     "transitive fragments in order of use",
+			document: `{ a: repository(name: "a", owner: "b") { ...Card } b: repository(name: "b", owner: "b") { ...Language } }`,
+			expected: `{ a: repository(name: "a", owner: "b") { ...Card } b: repository(name: "b", owner: "b") { ...Language } }` +
+				"\n\nfragment Card on Repository { ...Summary isArchived }" +
+				"\n\nfragment Summary on Repository { name ...Language }" +
+				"\n\nfragment Language on Repository { primaryLanguage { name } }",
+		},
+		{
+			name:     "fragments the document defines itself are left alone",
+			document: "{ repository(name: \"a\", owner: \"b\") { ...Summary } }\nfragment Summary on Repository { name }",
+			expected: "{ repository(name: \"a\", owner: \"b\") { ...Summary } }\nfragment Summary on Repository { name }",
+		},
+		{
+			name:     "spreads inside inline fragments",
+			document: `{ repositoryOwner(login: "a") { ... on User { repository(name: "b") { ...Language } } } }`,
+			expected: `{ repositoryOwner(login: "a") { ... on User { repository(name: "b") { ...Language } } } }` +
+				"\n\nfragment Language on Repository { primaryLanguage { name } }",
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Act
+			completed, err := registry.Complete(tt.document)
+
+			// Assert
+			uts.NoError(err)
+			uts.Equal(tt.expected, completed)
+		})
+	}
+}
+
+func (uts *UnitTestFragmentRegistrySuite) Test_CompleteUndefinedFragment() {
+	// Arrange
+	registry := graphql.NewFragmentRegistry()
+
+	// Act
+	_, err := registry.Complete(`{ repository(name: "a", owner: "b") { ...Missing } }`)
+
+	// Assert
+	var validationError *graphql.ValidationError
+	uts.ErrorAs(err, &validationError)
+}
+
+func (uts *UnitTestFragmentRegistrySuite) Test_Register() {
+	tests := []struct {
+		name       string
+		definition string
+		wantErr    bool
+	}{
+		{
+			name:       "fragment",
+			definition: "fragment Other on Repository { name }",
+		},
+		{
+			name:       "already registered",
+			definition: "fragment Summary on Repository { forkCount }",
+			wantErr:    true,
+		},
+		{
+			name:       "operation",
+			definition: "query A { viewer { login } }",
+			wantErr:    true,
+		},
+		{
+			name:       "two fragments",
+			definition: "fragment A on Repository { name } fragment B on Repository { name }",
+			wantErr:    true,
+		},
+		{
+			name:       "syntax error",
+			definition: "fragment A on Repository {",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Arrange
+			registry := graphql.MustFragmentRegistry("fragment Summary on Repository { name }")
+
+			// Act
+			err := registry.Register(tt.definition)
+
+			// Assert
+			if tt.wantErr {
+				uts.Error(err)
+				return
+			}
+			uts.NoError(err)
+			uts.NotNil(registry.Fragment("Other"))
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Package graphql parses GraphQL documents, both the queries our models
+// send and schema definitions, into a small AST.
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenBlockString
+)
+
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   Position
+}
+
+// SyntaxError is returned for documents that don't parse.
+type SyntaxError struct {
+	Message string
+	Pos     Position
+}
+
+func (e *SyntaxError) Error() string {
+	return "graphql: syntax error at " + e.Pos.String() + ": " + e.Message
+}
+
+type lexer struct {
+	source string
+	offset int
+	line   int
+	column int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{
+		source: source,
+		line:   1,
+		column: 1,
+	}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.offset < len(l.source); i++ {
+		if l.source[l.offset] == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+		l.offset++
+	}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...any) error {
+	return &SyntaxError{
+		Message: fmt.Sprintf(format, args...),
+		Pos:     pos,
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.offset < len(l.source) {
+		switch ch := l.source[l.offset]; {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n' || ch == ',':
+			l.advance(1)
+		case ch == '#':
+			for l.offset < len(l.source) && l.source[l.offset] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.source[l.offset:], "\ufeff"):
+			l.advance(len("\ufeff"))
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	pos := Position{Line: l.line, Column: l.column}
+	if l.offset >= len(l.source) {
+		return token{kind: tokenEOF, pos: pos}, nil
+	}
+
+	rest := l.source[l.offset:]
+	switch ch := rest[0]; {
+	case strings.HasPrefix(rest, "..."):
+		l.advance(3)
+		return token{kind: tokenPunctuator, value: "...", pos: pos}, nil
+	case strings.IndexByte("!$&():=@[]{}|", ch) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunctuator, value: string(ch), pos: pos}, nil
+	case isNameStart(ch):
+		end := 1
+		for end < len(rest) && isNameContinue(rest[end]) {
+			end++
+		}
+		l.advance(end)
+		return token{kind: tokenName, value: rest[:end], pos: pos}, nil
+	case ch == '-' || isDigit(ch):
+		return l.number(pos)
+	case strings.HasPrefix(rest, `"""`):
+		end := strings.Index(rest[3:], `"""`)
+		for end >= 0 && rest[3+end-1] == '\\' {
+			next := strings.Index(rest[3+end+3:], `"""`)
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += 3 + next
+		}
+		if end < 0 {
+			return token{}, l.errorf(pos, "unterminated block string")
+		}
+		value := blockStringValue(strings.ReplaceAll(rest[3:3+end], `\"""`, `"""`))
+		l.advance(3 + end + 3)
+		return token{kind: tokenBlockString, value: value, pos: pos}, nil
+	case ch == '"':
+		return l.string(pos)
+	}
+	return token{}, l.errorf(pos, "unexpected character %q", rest[0])
+}
+
+func (l *lexer) number(pos Position) (token, error) {
+	rest := l.source[l.offset:]
+	end := 0
+	if rest[end] == '-' {
+		end++
+	}
+	digits := end
+	for end < len(rest) && isDigit(rest[end]) {
+		end++
+	}
+	if end == digits {
+		return token{}, l.errorf(pos, "invalid number")
+	}
+	kind := tokenInt
+	if end < len(rest) && rest[end] == '.' {
+		kind = tokenFloat
+		end++
+		for end < len(rest) && isDigit(rest[end]) {
+			end++
+		}
+	}
+	if end < len(rest) && (rest[end] == 'e' || rest[end] == 'E') {
+		kind = tokenFloat
+		end++
+		if end < len(rest) && (rest[end] == '+' || rest[end] == '-') {
+			end++
+		}
+		for end < len(rest) && isDigit(rest[end]) {
+			end++
+		}
+	}
+	if end < len(rest) && isNameStart(rest[end]) {
+		return token{}, l.errorf(pos, "invalid number")
+	}
+	l.advance(end)
+	return token{kind: kind, value: rest[:end], pos: pos}, nil
+}
+
+func (l *lexer) string(pos Position) (token, error) {
+	rest := l.source[l.offset:]
+	var value strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch ch := rest[i]; ch {
+		case '"':
+			l.advance(i + 1)
+			return token{kind: tokenString, value: value.String(), pos: pos}, nil
+		case '\n':
+			return token{}, l.errorf(pos, "unterminated string")
+		case '\\':
+			if i+1 >= len(rest) {
+				return token{}, l.errorf(pos, "unterminated string")
+			}
+			i++
+			switch escaped := rest[i]; escaped {
+			case '"', '\\', '/':
+				value.WriteByte(escaped)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				var r rune
+				if i+4 >= len(rest) {
+					return token{}, l.errorf(pos, "invalid unicode escape")
+				}
+				if _, err := fmt.Sscanf(rest[i+1:i+5], "%04x", &r); err != nil {
+					return token{}, l.errorf(pos, "invalid unicode escape")
+				}
+				value.WriteRune(r)
+				i += 4
+			default:
+				return token{}, l.errorf(pos, "invalid escape \\%c", escaped)
+			}
+		default:
+			value.WriteByte(ch)
+		}
+	}
+	return token{}, l.errorf(pos, "unterminated string")
+}
+
+// blockStringValue strips the common indentation and the blank first and
+// last lines of a block string, as the spec asks for.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if width := len(line) - len(trimmed); indent < 0 || width < indent {
+			indent = width
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isNameContinue(ch byte) bool {
+	return isNameStart(ch) || isDigit(ch)
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
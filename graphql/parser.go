@@ -0,0 +1,441 @@
+package graphql
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func newParser(source string) (*parser, error) {
+	p := &parser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	next, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = next
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return p.lexer.errorf(p.token.pos, format, args...)
+}
+
+func (p *parser) peek(punctuator string) bool {
+	return p.token.kind == tokenPunctuator && p.token.value == punctuator
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.token.kind == tokenName && p.token.value == keyword
+}
+
+// skip consumes punctuator when it is next and reports whether it was.
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(punctuator) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.errorf("expected %q, found %s", punctuator, p.describe())
+	}
+	return p.advance()
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.peekKeyword(keyword) {
+		return p.errorf("expected %q, found %s", keyword, p.describe())
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.errorf("expected name, found %s", p.describe())
+	}
+	value := p.token.value
+	return value, p.advance()
+}
+
+func (p *parser) describe() string {
+	switch p.token.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString, tokenBlockString:
+		return "string"
+	}
+	return "\"" + p.token.value + "\""
+}
+
+// Parse parses an executable document.
+func Parse(source string) (*Document, error) {
+	p, err := newParser(source)
+	if err != nil {
+		return nil, err
+	}
+	document := new(Document)
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			document.Operations = append(document.Operations, operation)
+		case p.peekKeyword("fragment"):
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			document.Fragments = append(document.Fragments, fragment)
+		case p.peekKeyword(string(OperationQuery)), p.peekKeyword(string(OperationMutation)), p.peekKeyword(string(OperationSubscription)):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			document.Operations = append(document.Operations, operation)
+		default:
+			return nil, p.errorf("unexpected %s", p.describe())
+		}
+	}
+	if len(document.Operations) == 0 && len(document.Fragments) == 0 {
+		return nil, p.errorf("empty document")
+	}
+	return document, nil
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{
+		Kind: OperationQuery,
+		Pos:  p.token.pos,
+	}
+	if p.peek("{") {
+		selectionSet, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		operation.SelectionSet = selectionSet
+		return operation, nil
+	}
+
+	operation.Kind = OperationKind(p.token.value)
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenName {
+		operation.Name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		definitions, err := p.variableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		operation.VariableDefinitions = definitions
+	}
+	directives, err := p.directives()
+	if err != nil {
+		return nil, err
+	}
+	operation.Directives = directives
+	if operation.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (p *parser) variableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var definitions []*VariableDefinition
+	for !p.peek(")") {
+		definition := &VariableDefinition{Pos: p.token.pos}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		definition.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if definition.Type, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if definition.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, p.advance()
+}
+
+func (p *parser) typeReference() (*Type, error) {
+	var t *Type
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.typeReference()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = &Type{Elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t = &Type{Name: name}
+	}
+	nonNull, err := p.skip("!")
+	t.NonNull = nonNull
+	return t, err
+}
+
+func (p *parser) fragment() (*Fragment, error) {
+	fragment := &Fragment{Pos: p.token.pos}
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.lexer.errorf(fragment.Pos, "fragment can't be called \"on\"")
+	}
+	fragment.Name = name
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) selectionSet() (SelectionSet, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections SelectionSet
+	for !p.peek("}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (Selection, error) {
+	pos := p.token.pos
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.token.kind == tokenName && p.token.value != "on" {
+			spread := &FragmentSpread{Name: p.token.value, Pos: pos}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			spread.Directives, err = p.directives()
+			return spread, err
+		}
+		inline := &InlineFragment{Pos: pos}
+		if p.peekKeyword("on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.TypeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		inline.SelectionSet, err = p.selectionSet()
+		return inline, err
+	}
+
+	field := &Field{Pos: pos}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+	if field.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if field.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*Argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var arguments []*Argument
+	for !p.peek(")") {
+		argument := &Argument{Pos: p.token.pos}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		argument.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if argument.Value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+	if len(arguments) == 0 {
+		return nil, p.errorf("empty argument list")
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) directives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek("@") {
+		directive := &Directive{Pos: p.token.pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		directive.Name = name
+		if directive.Arguments, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+// value parses a literal. Variables are refused where constant is set, as
+// in default values.
+func (p *parser) value(constant bool) (*Value, error) {
+	value := &Value{
+		Raw: p.token.value,
+		Pos: p.token.pos,
+	}
+	switch p.token.kind {
+	case tokenInt:
+		value.Kind = ValueInt
+	case tokenFloat:
+		value.Kind = ValueFloat
+	case tokenString, tokenBlockString:
+		value.Kind = ValueString
+	case tokenName:
+		switch p.token.value {
+		case "true", "false":
+			value.Kind = ValueBoolean
+		case "null":
+			value.Kind = ValueNull
+		default:
+			value.Kind = ValueEnum
+		}
+	case tokenPunctuator:
+		switch p.token.value {
+		case "$":
+			if constant {
+				return nil, p.errorf("unexpected variable in constant value")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			value.Kind, value.Raw = ValueVariable, name
+			return value, err
+		case "[":
+			value.Kind, value.Raw = ValueList, ""
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.List = append(value.List, item)
+			}
+			return value, p.advance()
+		case "{":
+			value.Kind, value.Raw = ValueObject, ""
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				fieldValue, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				value.Fields = append(value.Fields, &ObjectField{Name: name, Value: fieldValue})
+			}
+			return value, p.advance()
+		default:
+			return nil, p.errorf("expected value, found %s", p.describe())
+		}
+	default:
+		return nil, p.errorf("expected value, found %s", p.describe())
+	}
+	return value, p.advance()
+}
@@ -0,0 +1,109 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestParserSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestParserSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestParserSuite))
+}
+
+func (uts *UnitTestParserSuite) Test_ParsesOperationsAndFragments() {
+	document, err := graphql.Parse(`
+		# leading comment
+		query Card($name: String!, $owner: String! = "octocat", $first: Int = 1) {
+			card: repository(name: $name, owner: $owner) {
+				...RepositoryCard
+				languages(first: $first, orderBy: {field: SIZE, direction: DESC}) {
+					nodes { name }
+				}
+				... on Repository @include(if: true) { forkCount }
+			}
+		}
+
+		fragment RepositoryCard on Repository {
+			name
+			description
+		}`)
+	uts.Require().Nil(err)
+
+	operation := document.Operation("")
+	uts.Require().NotNil(operation)
+	uts.Equal(graphql.OperationQuery, operation.Kind)
+	uts.Equal("Card", operation.Name)
+	uts.Len(operation.VariableDefinitions, 3)
+	uts.Equal("String!", operation.VariableDefinitions[0].Type.String())
+	uts.Equal(graphql.ValueString, operation.VariableDefinitions[1].Default.Kind)
+
+	field := operation.SelectionSet[0].(*graphql.Field)
+	uts.Equal("card", field.ResponseKey())
+	uts.Equal("repository", field.Name)
+	uts.Equal(graphql.Position{Line: 4, Column: 4}, field.Pos)
+	owner, err := field.Argument("owner").Value.Resolve(map[string]any{"owner": "hubot"})
+	uts.Nil(err)
+	uts.Equal("hubot", owner)
+
+	uts.IsType(new(graphql.FragmentSpread), field.SelectionSet[0])
+	languages := field.SelectionSet[1].(*graphql.Field)
+	orderBy, err := languages.Argument("orderBy").Value.Resolve(nil)
+	uts.Nil(err)
+	uts.Equal(map[string]any{"field": "SIZE", "direction": "DESC"}, orderBy)
+	inline := field.SelectionSet[2].(*graphql.InlineFragment)
+	uts.Equal("Repository", inline.TypeCondition)
+	uts.Equal("include", inline.Directives[0].Name)
+
+	fragment := document.Fragment("RepositoryCard")
+	uts.Require().NotNil(fragment)
+	uts.Equal("Repository", fragment.TypeCondition)
+	uts.Len(fragment.SelectionSet, 2)
+}
+
+func (uts *UnitTestParserSuite) Test_StringsAndNumbers() {
+	document, err := graphql.Parse(`{ search(query: "say \"hi\"!", text: """
+			first
+			  second
+		""", first: -1, score: 1.5e3) { count } }`)
+	uts.Require().Nil(err)
+
+	field := document.Operations[0].SelectionSet[0].(*graphql.Field)
+	uts.Equal(`say "hi"!`, field.Argument("query").Value.Raw)
+	uts.Equal("first\n  second", field.Argument("text").Value.Raw)
+	uts.Equal(graphql.ValueInt, field.Argument("first").Value.Kind)
+	score, _ := field.Argument("score").Value.Resolve(nil)
+	uts.Equal(1500.0, score)
+}
+
+func (uts *UnitTestParserSuite) Test_SyntaxErrors() {
+	var tests = []struct {
+		testName string
+		document string
+		line     int
+	}{
+		{testName: "empty", document: "  # nothing\n"},
+		{testName: "unclosed selection", document: "query {\n\tviewer {\n\t\tlogin\n\t}\n", line: 5},
+		{testName: "empty selection", document: "{ viewer { } }", line: 1},
+		{testName: "variable in default", document: "query ($a: Int = $b) { viewer }", line: 1},
+		{testName: "unterminated string", document: "{\n\tuser(login: \"octocat) { login }\n}", line: 2},
+		{testName: "bad character", document: "{ viewer % }", line: 1},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			_, err := graphql.Parse(v.document)
+			var syntaxError *graphql.SyntaxError
+			uts.Require().ErrorAs(err, &syntaxError)
+			if v.line > 0 {
+				uts.Equal(v.line, syntaxError.Pos.Line)
+			}
+		})
+	}
+}
@@ -0,0 +1,531 @@
+package graphql
+
+import "fmt"
+
+type TypeKind string
+
+const (
+	KindScalar      TypeKind = "SCALAR"
+	KindObject      TypeKind = "OBJECT"
+	KindInterface   TypeKind = "INTERFACE"
+	KindUnion       TypeKind = "UNION"
+	KindEnum        TypeKind = "ENUM"
+	KindInputObject TypeKind = "INPUT_OBJECT"
+)
+
+// Schema is a parsed type system document (SDL).
+type Schema struct {
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+	Types            map[string]*TypeDefinition
+}
+
+// TypeDefinition describes a named type. Fields are set for objects and
+// interfaces, InputFields for input objects, EnumValues for enums and
+// PossibleTypes for unions.
+type TypeDefinition struct {
+	Kind          TypeKind
+	Name          string
+	Description   string
+	Interfaces    []string
+	Fields        []*FieldDefinition
+	InputFields   []*InputValueDefinition
+	EnumValues    []string
+	PossibleTypes []string
+	Pos           Position
+}
+
+type FieldDefinition struct {
+	Name        string
+	Description string
+	Arguments   []*InputValueDefinition
+	Type        *Type
+	Deprecated  bool
+}
+
+type InputValueDefinition struct {
+	Name        string
+	Description string
+	Type        *Type
+	Default     *Value
+}
+
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+func (t *TypeDefinition) Field(name string) *FieldDefinition {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (t *TypeDefinition) InputField(name string) *InputValueDefinition {
+	for _, field := range t.InputFields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (t *TypeDefinition) HasEnumValue(value string) bool {
+	for _, enumValue := range t.EnumValues {
+		if enumValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLeaf tells scalars and enums, which take no selections, apart.
+func (t *TypeDefinition) IsLeaf() bool {
+	return t.Kind == KindScalar || t.Kind == KindEnum
+}
+
+// IsComposite tells the types that need a selection set.
+func (t *TypeDefinition) IsComposite() bool {
+	return t.Kind == KindObject || t.Kind == KindInterface || t.Kind == KindUnion
+}
+
+// IsInput tells the types variables and arguments may have.
+func (t *TypeDefinition) IsInput() bool {
+	return t.IsLeaf() || t.Kind == KindInputObject
+}
+
+func (f *FieldDefinition) Argument(name string) *InputValueDefinition {
+	for _, argument := range f.Arguments {
+		if argument.Name == name {
+			return argument
+		}
+	}
+	return nil
+}
+
+// RootType returns the type operations of kind start from.
+func (s *Schema) RootType(kind OperationKind) *TypeDefinition {
+	switch kind {
+	case OperationQuery:
+		return s.Types[s.QueryType]
+	case OperationMutation:
+		return s.Types[s.MutationType]
+	case OperationSubscription:
+		return s.Types[s.SubscriptionType]
+	}
+	return nil
+}
+
+// PossibleTypes lists the object types a value of type name can be.
+func (s *Schema) PossibleTypes(name string) []string {
+	definition := s.Types[name]
+	if definition == nil {
+		return nil
+	}
+	switch definition.Kind {
+	case KindObject:
+		return []string{name}
+	case KindUnion:
+		return definition.PossibleTypes
+	case KindInterface:
+		var possible []string
+		for _, candidate := range s.Types {
+			if candidate.Kind != KindObject {
+				continue
+			}
+			for _, implemented := range candidate.Interfaces {
+				if implemented == name {
+					possible = append(possible, candidate.Name)
+				}
+			}
+		}
+		return possible
+	}
+	return nil
+}
+
+// ParseSchema parses a type system document. Directive definitions are
+// read but not kept.
+func ParseSchema(source string) (*Schema, error) {
+	p, err := newParser(source)
+	if err != nil {
+		return nil, err
+	}
+	schema := &Schema{Types: map[string]*TypeDefinition{}}
+	for _, name := range builtinScalars {
+		schema.Types[name] = &TypeDefinition{Kind: KindScalar, Name: name}
+	}
+	explicitRoots := false
+	for p.token.kind != tokenEOF {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+		extend := p.peekKeyword("extend")
+		if extend {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.token.kind != tokenName {
+			return nil, p.errorf("expected definition, found %s", p.describe())
+		}
+		switch p.token.value {
+		case "schema":
+			if err := p.schemaDefinition(schema); err != nil {
+				return nil, err
+			}
+			explicitRoots = true
+		case "directive":
+			if err := p.directiveDefinition(); err != nil {
+				return nil, err
+			}
+		case "scalar", "type", "interface", "union", "enum", "input":
+			definition, err := p.typeDefinition()
+			if err != nil {
+				return nil, err
+			}
+			definition.Description = description
+			existing, ok := schema.Types[definition.Name]
+			switch {
+			case extend && ok && existing.Kind == definition.Kind:
+				existing.Interfaces = append(existing.Interfaces, definition.Interfaces...)
+				existing.Fields = append(existing.Fields, definition.Fields...)
+				existing.InputFields = append(existing.InputFields, definition.InputFields...)
+				existing.EnumValues = append(existing.EnumValues, definition.EnumValues...)
+				existing.PossibleTypes = append(existing.PossibleTypes, definition.PossibleTypes...)
+			case extend:
+				return nil, p.lexer.errorf(definition.Pos, "can't extend undefined %s %q", definition.Kind, definition.Name)
+			case ok:
+				return nil, p.lexer.errorf(definition.Pos, "type %q is defined twice", definition.Name)
+			default:
+				schema.Types[definition.Name] = definition
+			}
+		default:
+			return nil, p.errorf("unexpected %s", p.describe())
+		}
+	}
+
+	if !explicitRoots {
+		for name, root := range map[string]*string{
+			"Query":        &schema.QueryType,
+			"Mutation":     &schema.MutationType,
+			"Subscription": &schema.SubscriptionType,
+		} {
+			if _, ok := schema.Types[name]; ok {
+				*root = name
+			}
+		}
+	}
+	if schema.QueryType == "" {
+		return nil, fmt.Errorf("graphql: schema has no query type")
+	}
+	return schema, schema.check()
+}
+
+// check makes sure every type a definition refers to is defined.
+func (s *Schema) check() error {
+	known := func(t *Type, where string) error {
+		if _, ok := s.Types[t.NamedType()]; !ok {
+			return fmt.Errorf("graphql: %s refers to undefined type %q", where, t.NamedType())
+		}
+		return nil
+	}
+	for _, definition := range s.Types {
+		for _, field := range definition.Fields {
+			if err := known(field.Type, definition.Name+"."+field.Name); err != nil {
+				return err
+			}
+			for _, argument := range field.Arguments {
+				if err := known(argument.Type, definition.Name+"."+field.Name+"("+argument.Name+")"); err != nil {
+					return err
+				}
+			}
+		}
+		for _, field := range definition.InputFields {
+			if err := known(field.Type, definition.Name+"."+field.Name); err != nil {
+				return err
+			}
+		}
+		for _, name := range append(append([]string(nil), definition.Interfaces...), definition.PossibleTypes...) {
+			if _, ok := s.Types[name]; !ok {
+				return fmt.Errorf("graphql: %s refers to undefined type %q", definition.Name, name)
+			}
+		}
+	}
+	for _, root := range []string{s.QueryType, s.MutationType, s.SubscriptionType} {
+		if _, ok := s.Types[root]; root != "" && !ok {
+			return fmt.Errorf("graphql: root type %q is undefined", root)
+		}
+	}
+	return nil
+}
+
+func (p *parser) description() (string, error) {
+	if p.token.kind != tokenString && p.token.kind != tokenBlockString {
+		return "", nil
+	}
+	description := p.token.value
+	return description, p.advance()
+}
+
+func (p *parser) schemaDefinition(schema *Schema) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if _, err := p.directives(); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.peek("}") {
+		operation, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		switch OperationKind(operation) {
+		case OperationQuery:
+			schema.QueryType = name
+		case OperationMutation:
+			schema.MutationType = name
+		case OperationSubscription:
+			schema.SubscriptionType = name
+		default:
+			return p.errorf("unknown operation type %q", operation)
+		}
+	}
+	return p.advance()
+}
+
+func (p *parser) directiveDefinition() error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.peek("(") {
+		if _, err := p.inputValueDefinitions("(", ")"); err != nil {
+			return err
+		}
+	}
+	if p.peekKeyword("repeatable") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	if err := p.expectKeyword("on"); err != nil {
+		return err
+	}
+	if _, err := p.skip("|"); err != nil {
+		return err
+	}
+	for {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if ok, err := p.skip("|"); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+	}
+}
+
+func (p *parser) typeDefinition() (*TypeDefinition, error) {
+	definition := &TypeDefinition{Pos: p.token.pos}
+	keyword := p.token.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	definition.Name = name
+
+	switch keyword {
+	case "scalar":
+		definition.Kind = KindScalar
+		_, err = p.directives()
+	case "type", "interface":
+		definition.Kind = KindObject
+		if keyword == "interface" {
+			definition.Kind = KindInterface
+		}
+		if definition.Interfaces, err = p.implementsInterfaces(); err != nil {
+			return nil, err
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		if p.peek("{") {
+			definition.Fields, err = p.fieldDefinitions()
+		}
+	case "union":
+		definition.Kind = KindUnion
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil || !ok {
+			return definition, err
+		}
+		if _, err := p.skip("|"); err != nil {
+			return nil, err
+		}
+		for {
+			member, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			definition.PossibleTypes = append(definition.PossibleTypes, member)
+			if ok, err := p.skip("|"); err != nil {
+				return nil, err
+			} else if !ok {
+				break
+			}
+		}
+	case "enum":
+		definition.Kind = KindEnum
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("{"); err != nil || !ok {
+			return definition, err
+		}
+		for !p.peek("}") {
+			if _, err := p.description(); err != nil {
+				return nil, err
+			}
+			value, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			definition.EnumValues = append(definition.EnumValues, value)
+		}
+		err = p.advance()
+	case "input":
+		definition.Kind = KindInputObject
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		if p.peek("{") {
+			definition.InputFields, err = p.inputValueDefinitions("{", "}")
+		}
+	}
+	return definition, err
+}
+
+func (p *parser) implementsInterfaces() ([]string, error) {
+	if !p.peekKeyword("implements") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if _, err := p.skip("&"); err != nil {
+		return nil, err
+	}
+	var interfaces []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, name)
+		if ok, err := p.skip("&"); err != nil {
+			return nil, err
+		} else if !ok {
+			return interfaces, nil
+		}
+	}
+}
+
+func (p *parser) fieldDefinitions() ([]*FieldDefinition, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*FieldDefinition
+	for !p.peek("}") {
+		field := new(FieldDefinition)
+		var err error
+		if field.Description, err = p.description(); err != nil {
+			return nil, err
+		}
+		if field.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.peek("(") {
+			if field.Arguments, err = p.inputValueDefinitions("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if field.Type, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		directives, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		for _, directive := range directives {
+			if directive.Name == "deprecated" {
+				field.Deprecated = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, p.advance()
+}
+
+func (p *parser) inputValueDefinitions(open string, close string) ([]*InputValueDefinition, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+	var values []*InputValueDefinition
+	for !p.peek(close) {
+		value := new(InputValueDefinition)
+		var err error
+		if value.Description, err = p.description(); err != nil {
+			return nil, err
+		}
+		if value.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if value.Type, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if value.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, p.advance()
+}
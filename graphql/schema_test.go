@@ -0,0 +1,166 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestSchemaSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestSchemaSuite))
+}
+
+const testSchema = `
+"""
+A snapshot fit for tests.
+"""
+schema {
+	query: Root
+}
+
+directive @deprecated(reason: String = "No longer supported") on FIELD_DEFINITION | ENUM_VALUE
+
+scalar DateTime
+
+type Root {
+	"Lookup a repository."
+	repository(name: String!, owner: String!, followRenames: Boolean = true): Repository
+	owner(login: String!): Owner
+	search(query: String!, first: Int): [SearchResult!]!
+}
+
+interface Owner {
+	login: String!
+	repositories(first: Int, last: Int, after: String, orderBy: RepositoryOrder): RepositoryConnection!
+}
+
+type User implements Owner {
+	login: String!
+	repositories(first: Int, last: Int, after: String, orderBy: RepositoryOrder): RepositoryConnection!
+}
+
+type Organization implements & Owner {
+	login: String!
+	repositories(first: Int, last: Int, after: String, orderBy: RepositoryOrder): RepositoryConnection!
+}
+
+type Repository {
+	name: String!
+	owner: Owner!
+	createdAt: DateTime!
+	openIssueCount: Int! @deprecated(reason: "Use issues instead.")
+	languages(first: Int, orderBy: LanguageOrder): LanguageConnection
+}
+
+type RepositoryConnection {
+	totalCount: Int!
+	nodes: [Repository]
+}
+
+type LanguageConnection {
+	nodes: [Language]
+}
+
+type Language {
+	name: String!
+}
+
+union SearchResult = | Repository | User
+
+enum OrderDirection {
+	"Ascending"
+	ASC
+	DESC
+}
+
+enum LanguageOrderField { SIZE }
+
+input LanguageOrder {
+	field: LanguageOrderField!
+	direction: OrderDirection!
+}
+
+input RepositoryOrder {
+	field: String!
+	direction: OrderDirection = ASC
+}
+
+extend type Repository {
+	stargazerCount: Int!
+}
+`
+
+func (uts *UnitTestSchemaSuite) Test_ParsesTypeDefinitions() {
+	// Act
+	schema, err := graphql.ParseSchema(testSchema)
+
+	// Assert
+	uts.Require().NoError(err)
+	uts.Equal("Root", schema.QueryType)
+	uts.Empty(schema.MutationType)
+
+	repository := schema.Types["Repository"]
+	uts.Require().NotNil(repository)
+	uts.Equal(graphql.KindObject, repository.Kind)
+	uts.NotNil(repository.Field("stargazerCount"), "extensions add fields")
+	uts.True(repository.Field("openIssueCount").Deprecated)
+
+	lookup := schema.Types["Root"].Field("repository")
+	uts.Equal("Lookup a repository.", lookup.Description)
+	uts.Equal("String!", lookup.Argument("owner").Type.String())
+	uts.Equal("true", lookup.Argument("followRenames").Default.Raw)
+
+	uts.Equal([]string{"Repository", "User"}, schema.Types["SearchResult"].PossibleTypes)
+	uts.Equal([]string{"ASC", "DESC"}, schema.Types["OrderDirection"].EnumValues)
+	uts.Equal(graphql.KindInputObject, schema.Types["LanguageOrder"].Kind)
+	uts.Equal(graphql.KindScalar, schema.Types["DateTime"].Kind)
+	uts.Equal(graphql.KindScalar, schema.Types["Int"].Kind, "built-in scalars are always defined")
+	uts.ElementsMatch([]string{"User", "Organization"}, schema.PossibleTypes("Owner"))
+}
+
+func (uts *UnitTestSchemaSuite) Test_InvalidSchemas() {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{
+			name:   "no query type",
+			source: `type Repository { name: String! }`,
+		},
+		{
+			name:   "undefined field type",
+			source: `type Query { repository: Repository }`,
+		},
+		{
+			name:   "undefined argument type",
+			source: `type Query { name(order: Order): String }`,
+		},
+		{
+			name:   "type defined twice",
+			source: `type Query { name: String } type Query { login: String }`,
+		},
+		{
+			name:   "extending an undefined type",
+			source: `type Query { name: String } extend type Repository { name: String }`,
+		},
+		{
+			name:   "syntax error",
+			source: `type Query { name String }`,
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Act
+			_, err := graphql.ParseSchema(tt.source)
+
+			// Assert
+			uts.Error(err)
+		})
+	}
+}
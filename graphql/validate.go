@@ -0,0 +1,430 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError is a document that doesn't fit the schema. Messages
+// follow the wording api.github.com uses for the same mistakes.
+type ValidationError struct {
+	Message string
+	Pos     Position
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("graphql: %s: %s", e.Pos, e.Message)
+}
+
+type validator struct {
+	schema   *Schema
+	document *Document
+	errors   []error
+	seen     map[string]bool
+
+	usedFragments map[string]bool
+	// Set while walking one operation, fragments spread into it included.
+	variables     map[string]*VariableDefinition
+	usedVariables map[string]bool
+	spreading     map[string]bool
+}
+
+// Validate checks document against schema: every field must exist on its
+// parent type, arguments must be known, present when required and of the
+// right type, variables must be declared, used and fit where they're used,
+// and leaf fields take no selections while others need them. Errors come
+// back in document order, nil when there are none.
+func Validate(schema *Schema, document *Document) []error {
+	v := &validator{
+		schema:        schema,
+		document:      document,
+		seen:          map[string]bool{},
+		usedFragments: map[string]bool{},
+	}
+
+	operationNames := map[string]bool{}
+	for _, operation := range document.Operations {
+		if operation.Name == "" && len(document.Operations) > 1 {
+			v.errorf(operation.Pos, "This anonymous operation must be the only defined operation.")
+		}
+		if operation.Name != "" && operationNames[operation.Name] {
+			v.errorf(operation.Pos, "Operation name %q must be unique", operation.Name)
+		}
+		operationNames[operation.Name] = true
+		v.operation(operation)
+	}
+
+	fragmentNames := map[string]bool{}
+	for _, fragment := range document.Fragments {
+		if fragmentNames[fragment.Name] {
+			v.errorf(fragment.Pos, "Fragment name %q must be unique", fragment.Name)
+		}
+		fragmentNames[fragment.Name] = true
+		if !v.usedFragments[fragment.Name] {
+			// Still check unused fragments, so documents holding nothing
+			// but fragments are validated too.
+			v.variables, v.usedVariables, v.spreading = nil, map[string]bool{}, map[string]bool{}
+			v.fragmentSpread(nil, &FragmentSpread{Name: fragment.Name, Pos: fragment.Pos})
+			if len(document.Operations) > 0 {
+				v.errorf(fragment.Pos, "Fragment %s was defined, but not used", fragment.Name)
+			}
+		}
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		a, b := v.errors[i].(*ValidationError).Pos, v.errors[j].(*ValidationError).Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return v.errors
+}
+
+func (v *validator) errorf(pos Position, format string, args ...any) {
+	err := &ValidationError{Message: fmt.Sprintf(format, args...), Pos: pos}
+	// Fragments spread into several operations are walked once per
+	// operation, report what's wrong with them only once.
+	if key := err.Error(); !v.seen[key] {
+		v.seen[key] = true
+		v.errors = append(v.errors, err)
+	}
+}
+
+func (v *validator) operation(operation *Operation) {
+	v.variables = map[string]*VariableDefinition{}
+	v.usedVariables = map[string]bool{}
+	v.spreading = map[string]bool{}
+
+	for _, definition := range operation.VariableDefinitions {
+		if v.variables[definition.Name] != nil {
+			v.errorf(definition.Pos, "There can only be one variable named \"%s\"", definition.Name)
+		}
+		v.variables[definition.Name] = definition
+		named := v.schema.Types[definition.Type.NamedType()]
+		switch {
+		case named == nil:
+			v.errorf(definition.Pos, "%s isn't a defined input type (on $%s)", definition.Type.NamedType(), definition.Name)
+		case !named.IsInput():
+			v.errorf(definition.Pos, "%s isn't a valid input type (on $%s)", definition.Type.NamedType(), definition.Name)
+		case definition.Default != nil:
+			v.value(definition.Default, definition.Type, fmt.Sprintf("Default value for $%s", definition.Name))
+		}
+	}
+
+	root := v.schema.RootType(operation.Kind)
+	if root == nil {
+		v.errorf(operation.Pos, "Schema is not configured for %ss", operation.Kind)
+	} else {
+		v.directives(operation.Directives)
+		v.selectionSet(root, operation.SelectionSet)
+	}
+
+	for _, definition := range operation.VariableDefinitions {
+		if !v.usedVariables[definition.Name] {
+			v.errorf(definition.Pos, "Variable $%s is declared by %s but not used", definition.Name, describeOperation(operation))
+		}
+	}
+}
+
+func describeOperation(operation *Operation) string {
+	if operation.Name == "" {
+		return "anonymous " + string(operation.Kind)
+	}
+	return operation.Name
+}
+
+func (v *validator) selectionSet(parent *TypeDefinition, set SelectionSet) {
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *Field:
+			v.field(parent, selection)
+		case *InlineFragment:
+			v.directives(selection.Directives)
+			typeCondition := parent
+			if selection.TypeCondition != "" {
+				typeCondition = v.typeCondition(parent, selection.TypeCondition, "Inline fragment", selection.Pos)
+			}
+			if typeCondition != nil {
+				v.selectionSet(typeCondition, selection.SelectionSet)
+			}
+		case *FragmentSpread:
+			v.fragmentSpread(parent, selection)
+		}
+	}
+}
+
+func (v *validator) fragmentSpread(parent *TypeDefinition, spread *FragmentSpread) {
+	v.directives(spread.Directives)
+	fragment := v.document.Fragment(spread.Name)
+	if fragment == nil {
+		v.errorf(spread.Pos, "Fragment %s was used, but not defined", spread.Name)
+		return
+	}
+	v.usedFragments[fragment.Name] = true
+	if v.spreading[fragment.Name] {
+		v.errorf(spread.Pos, "Fragment %s contains an infinite loop", fragment.Name)
+		return
+	}
+	v.spreading[fragment.Name] = true
+	defer delete(v.spreading, fragment.Name)
+
+	v.directives(fragment.Directives)
+	typeCondition := v.typeCondition(parent, fragment.TypeCondition, "Fragment "+fragment.Name, fragment.Pos)
+	if typeCondition != nil {
+		v.selectionSet(typeCondition, fragment.SelectionSet)
+	}
+}
+
+// typeCondition looks up the type a fragment applies to and makes sure it
+// can ever match a value of type parent, which is nil for fragments that
+// aren't spread anywhere.
+func (v *validator) typeCondition(parent *TypeDefinition, name string, what string, pos Position) *TypeDefinition {
+	condition := v.schema.Types[name]
+	if condition == nil {
+		v.errorf(pos, "No such type %s, so it can't be a fragment condition", name)
+		return nil
+	}
+	if !condition.IsComposite() {
+		v.errorf(pos, "Invalid fragment on type %s (must be Union, Interface or Object)", name)
+		return nil
+	}
+	if parent != nil && !overlaps(v.schema.PossibleTypes(parent.Name), v.schema.PossibleTypes(name)) {
+		v.errorf(pos, "%s on %s can't be spread inside %s", what, name, parent.Name)
+		return nil
+	}
+	return condition
+}
+
+func overlaps(a []string, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *validator) field(parent *TypeDefinition, field *Field) {
+	v.directives(field.Directives)
+	if field.Name == "__typename" {
+		if len(field.SelectionSet) > 0 {
+			v.errorf(field.Pos, "Selections can't be made on scalars (field '__typename' returns String but has selections)")
+		}
+		return
+	}
+	definition := parent.Field(field.Name)
+	if definition == nil {
+		v.errorf(field.Pos, "Field '%s' doesn't exist on type '%s'", field.Name, parent.Name)
+		return
+	}
+
+	for _, argument := range field.Arguments {
+		argumentDefinition := definition.Argument(argument.Name)
+		if argumentDefinition == nil {
+			v.errorf(argument.Pos, "Field '%s' doesn't accept argument '%s'", field.Name, argument.Name)
+			continue
+		}
+		v.value(argument.Value, argumentDefinition.Type, fmt.Sprintf("Argument '%s' on Field '%s'", argument.Name, field.ResponseKey()))
+	}
+	var missing []string
+	for _, argumentDefinition := range definition.Arguments {
+		if argumentDefinition.Type.NonNull && argumentDefinition.Default == nil && field.Argument(argumentDefinition.Name) == nil {
+			missing = append(missing, argumentDefinition.Name)
+		}
+	}
+	if len(missing) > 0 {
+		v.errorf(field.Pos, "Field '%s' is missing required arguments: %s", field.Name, strings.Join(missing, ", "))
+	}
+
+	fieldType := v.schema.Types[definition.Type.NamedType()]
+	switch {
+	case fieldType.IsLeaf() && len(field.SelectionSet) > 0:
+		v.errorf(field.Pos, "Selections can't be made on %ss (field '%s' returns %s but has selections)",
+			strings.ToLower(string(fieldType.Kind)), field.Name, fieldType.Name)
+	case !fieldType.IsLeaf() && len(field.SelectionSet) == 0:
+		v.errorf(field.Pos, "Field must have selections (field '%s' returns %s but has no selections. Did you mean '%s { ... }'?)",
+			field.Name, fieldType.Name, field.Name)
+	case !fieldType.IsLeaf():
+		v.selectionSet(fieldType, field.SelectionSet)
+	}
+}
+
+// directives checks @include and @skip, the only directives GitHub knows
+// in executable documents.
+func (v *validator) directives(directives []*Directive) {
+	for _, directive := range directives {
+		if directive.Name != "include" && directive.Name != "skip" {
+			v.errorf(directive.Pos, "Directive @%s is not defined", directive.Name)
+			continue
+		}
+		condition := &Type{Name: "Boolean", NonNull: true}
+		found := false
+		for _, argument := range directive.Arguments {
+			if argument.Name != "if" {
+				v.errorf(argument.Pos, "Directive '%s' doesn't accept argument '%s'", directive.Name, argument.Name)
+				continue
+			}
+			found = true
+			v.value(argument.Value, condition, fmt.Sprintf("Argument 'if' on Directive '%s'", directive.Name))
+		}
+		if !found {
+			v.errorf(directive.Pos, "Directive '%s' is missing required arguments: if", directive.Name)
+		}
+	}
+}
+
+// value checks that value fits into a location of type expected. where
+// describes the location for error messages.
+func (v *validator) value(value *Value, expected *Type, where string) {
+	if value.Kind == ValueVariable {
+		v.variable(value, expected, where)
+		return
+	}
+	if !v.literalFits(value, expected) {
+		v.errorf(value.Pos, "%s has an invalid value (%s). Expected type '%s'.", where, describeValue(value), expected)
+	}
+}
+
+func (v *validator) variable(value *Value, expected *Type, where string) {
+	v.usedVariables[value.Raw] = true
+	if v.variables == nil {
+		// A fragment that isn't spread anywhere, there are no variables
+		// to compare against.
+		return
+	}
+	definition := v.variables[value.Raw]
+	if definition == nil {
+		v.errorf(value.Pos, "Variable $%s is used by %s but not declared", value.Raw, where)
+		return
+	}
+	variableType := definition.Type
+	if expected.NonNull && !variableType.NonNull && definition.Default != nil {
+		// A default value makes up for a nullable variable in a
+		// non-null location.
+		copied := *variableType
+		copied.NonNull = true
+		variableType = &copied
+	}
+	if !typeFits(variableType, expected) {
+		mismatch := "Nullability"
+		if stripNonNull(variableType) != stripNonNull(expected) {
+			mismatch = "Type"
+		}
+		v.errorf(value.Pos, "%s mismatch on variable $%s and %s (%s / %s)",
+			mismatch, value.Raw, strings.ToLower(where[:1])+where[1:], variableType, expected)
+	}
+}
+
+// typeFits reports whether a variable of type given may be used where
+// expected is.
+func typeFits(given *Type, expected *Type) bool {
+	if expected.NonNull && !given.NonNull {
+		return false
+	}
+	if given.NonNull && !expected.NonNull {
+		copied := *given
+		copied.NonNull = false
+		return typeFits(&copied, expected)
+	}
+	if (given.Elem == nil) != (expected.Elem == nil) {
+		return false
+	}
+	if given.Elem != nil {
+		return typeFits(given.Elem, expected.Elem)
+	}
+	return given.Name == expected.Name
+}
+
+func stripNonNull(t *Type) string {
+	return strings.ReplaceAll(t.String(), "!", "")
+}
+
+func (v *validator) literalFits(value *Value, expected *Type) bool {
+	if value.Kind == ValueNull {
+		return !expected.NonNull
+	}
+	if value.Kind == ValueVariable {
+		// Variables nested in lists and objects are checked on their own.
+		v.variable(value, expected, "input field")
+		return true
+	}
+	if expected.Elem != nil {
+		if value.Kind != ValueList {
+			// Input coercion turns a single item into a list of one.
+			return v.literalFits(value, expected.Elem)
+		}
+		for _, item := range value.List {
+			if !v.literalFits(item, expected.Elem) {
+				return false
+			}
+		}
+		return true
+	}
+
+	named := v.schema.Types[expected.Name]
+	if named == nil {
+		return false
+	}
+	switch named.Kind {
+	case KindEnum:
+		return value.Kind == ValueEnum && named.HasEnumValue(value.Raw)
+	case KindInputObject:
+		if value.Kind != ValueObject {
+			return false
+		}
+		given := map[string]bool{}
+		for _, field := range value.Fields {
+			definition := named.InputField(field.Name)
+			if definition == nil || !v.literalFits(field.Value, definition.Type) {
+				return false
+			}
+			given[field.Name] = true
+		}
+		for _, definition := range named.InputFields {
+			if definition.Type.NonNull && definition.Default == nil && !given[definition.Name] {
+				return false
+			}
+		}
+		return true
+	}
+	switch named.Name {
+	case "Int":
+		return value.Kind == ValueInt
+	case "Float":
+		return value.Kind == ValueInt || value.Kind == ValueFloat
+	case "String":
+		return value.Kind == ValueString
+	case "Boolean":
+		return value.Kind == ValueBoolean
+	case "ID":
+		return value.Kind == ValueString || value.Kind == ValueInt
+	}
+	// Custom scalars such as DateTime or URI are serialized as strings but
+	// their formats aren't known here, accept any scalar literal.
+	return value.Kind != ValueList && value.Kind != ValueObject && value.Kind != ValueEnum
+}
+
+func describeValue(value *Value) string {
+	switch value.Kind {
+	case ValueString:
+		return fmt.Sprintf("%q", value.Raw)
+	case ValueList:
+		items := make([]string, len(value.List))
+		for i, item := range value.List {
+			items[i] = describeValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ValueObject:
+		fields := make([]string, len(value.Fields))
+		for i, field := range value.Fields {
+			fields[i] = field.Name + ": " + describeValue(field.Value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case ValueVariable:
+		return "$" + value.Raw
+	case ValueNull:
+		return "null"
+	}
+	return value.Raw
+}
@@ -0,0 +1,252 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestValidateSuite struct {
+	suite.Suite
+	schema *graphql.Schema
+}
+
+func TestUnitTestValidateSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestValidateSuite))
+}
+
+func (uts *UnitTestValidateSuite) SetupSuite() {
+	schema, err := graphql.ParseSchema(testSchema)
+	uts.Require().NoError(err)
+	uts.schema = schema
+}
+
+func (uts *UnitTestValidateSuite) validate(query string) []string {
+	document, err := graphql.Parse(query)
+	uts.Require().NoError(err)
+	var messages []string
+	for _, err := range graphql.Validate(uts.schema, document) {
+		messages = append(messages, err.(*graphql.ValidationError).Message)
+	}
+	return messages
+}
+
+func (uts *UnitTestValidateSuite) Test_ValidDocuments() {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{
+			name: "variables and literals",
+			query: `query Card($name: String!, $owner: String!, $first: Int = 1) {
+				repository(name: $name, owner: $owner) {
+					name
+					createdAt
+					languages(first: $first, orderBy: {field: SIZE, direction: DESC}) { nodes { name } }
+				}
+			}`,
+		},
+		{
+			name: "fragments on interfaces and unions",
+			query: `query Owner($login: String!, $skip: Boolean!) {
+				owner(login: $login) {
+					__typename
+					...OwnerRepositories @skip(if: $skip)
+					... on User { login }
+				}
+				search(query: "readme") {
+					... on Repository { name }
+					... on User { login }
+				}
+			}
+			fragment OwnerRepositories on Owner {
+				repositories(first: 10, orderBy: {field: "name"}) { totalCount nodes { name } }
+			}`,
+		},
+		{
+			name:  "non-null variable in nullable location",
+			query: `query ($first: Int!) { owner(login: "octocat") { repositories(first: $first) { totalCount } } }`,
+		},
+		{
+			name:  "nullable variable with default in non-null location",
+			query: `query ($owner: String = "octocat") { repository(name: "a", owner: $owner) { name } }`,
+		},
+		{
+			name:  "single value coerced into a list",
+			query: `{ search(query: "readme", first: 1) { __typename } }`,
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Act
+			messages := uts.validate(tt.query)
+
+			// Assert
+			uts.Empty(messages)
+		})
+	}
+}
+
+func (uts *UnitTestValidateSuite) Test_InvalidDocuments() {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "unknown field",
+			query:    `{ repository(name: "a", owner: "b") { nmae } }`,
+			expected: []string{"Field 'nmae' doesn't exist on type 'Repository'"},
+		},
+		{
+			name:     "unknown argument",
+			query:    `{ repository(name: "a", owner: "b", first: 1) { name } }`,
+			expected: []string{"Field 'repository' doesn't accept argument 'first'"},
+		},
+		{
+			name:     "missing required argument",
+			query:    `{ repository(name: "a") { name } }`,
+			expected: []string{"Field 'repository' is missing required arguments: owner"},
+		},
+		{
+			name:  "literals of the wrong type",
+			query: `{ repository(name: 1, owner: "b") { languages(orderBy: {field: NAME, direction: DESC}) { nodes { name } } } }`,
+			expected: []string{
+				"Argument 'name' on Field 'repository' has an invalid value (1). Expected type 'String!'.",
+				"Argument 'orderBy' on Field 'languages' has an invalid value ({field: NAME, direction: DESC}). Expected type 'LanguageOrder'.",
+			},
+		},
+		{
+			name:     "missing required input field",
+			query:    `{ repository(name: "a", owner: "b") { languages(orderBy: {field: SIZE}) { nodes { name } } } }`,
+			expected: []string{"Argument 'orderBy' on Field 'languages' has an invalid value ({field: SIZE}). Expected type 'LanguageOrder'."},
+		},
+		{
+			name:  "variable types",
+			query: `query ($name: String, $owner: Int!) { repository(name: $name, owner: $owner) { name } }`,
+			expected: []string{
+				"Nullability mismatch on variable $name and argument 'name' on Field 'repository' (String / String!)",
+				"Type mismatch on variable $owner and argument 'owner' on Field 'repository' (Int! / String!)",
+			},
+		},
+		{
+			name:  "undeclared and unused variables",
+			query: `query Card($unused: Int) { repository(name: $name, owner: "b") { name } }`,
+			expected: []string{
+				"Variable $unused is declared by Card but not used",
+				"Variable $name is used by Argument 'name' on Field 'repository' but not declared",
+			},
+		},
+		{
+			name:     "selection on a leaf",
+			query:    `{ repository(name: "a", owner: "b") { name { length } } }`,
+			expected: []string{"Selections can't be made on scalars (field 'name' returns String but has selections)"},
+		},
+		{
+			name:     "missing selection",
+			query:    `{ repository(name: "a", owner: "b") }`,
+			expected: []string{"Field must have selections (field 'repository' returns Repository but has no selections. Did you mean 'repository { ... }'?)"},
+		},
+		{
+			name: "fragments",
+			query: `{ repository(name: "a", owner: "b") { ...Missing ...OnLanguage } }
+			fragment OnLanguage on Language { name }
+			fragment Unused on Repository { name }`,
+			expected: []string{
+				"Fragment Missing was used, but not defined",
+				"Fragment OnLanguage on Language can't be spread inside Repository",
+				"Fragment Unused was defined, but not used",
+			},
+		},
+		{
+			name:     "unknown type condition",
+			query:    `{ owner(login: "a") { ... on Bot { login } } }`,
+			expected: []string{"No such type Bot, so it can't be a fragment condition"},
+		},
+		{
+			name:     "fragment cycle",
+			query:    `{ owner(login: "a") { ...A } } fragment A on Owner { ...B } fragment B on Owner { ...A }`,
+			expected: []string{"Fragment A contains an infinite loop"},
+		},
+		{
+			name:     "unknown directive",
+			query:    `{ owner(login: "a") @cached { login } }`,
+			expected: []string{"Directive @cached is not defined"},
+		},
+		{
+			name:     "schema without mutations",
+			query:    `mutation { addStar { clientMutationId } }`,
+			expected: []string{"Schema is not configured for mutations"},
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Act
+			messages := uts.validate(tt.query)
+
+			// Assert
+			uts.Equal(tt.expected, messages)
+		})
+	}
+}
+
+func (uts *UnitTestValidateSuite) Test_EstimateCost() {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		expected  graphql.Cost
+	}{
+		{
+			name:     "no connections",
+			query:    `{ repository(name: "a", owner: "b") { name } }`,
+			expected: graphql.Cost{Points: 1},
+		},
+		{
+			name: "nested connections multiply",
+			query: `query ($first: Int!) {
+				owner(login: "a") {
+					repositories(first: $first) { nodes { ...Languages } }
+				}
+			}
+			fragment Languages on Repository { languages(last: 10) { nodes { name } } }`,
+			variables: map[string]any{"first": 50},
+			expected:  graphql.Cost{Nodes: 50 + 50*10, Requests: 1 + 50, Points: 1},
+		},
+		{
+			name: "unknown sizes count as the maximum",
+			query: `query ($first: Int) {
+				owner(login: "a") {
+					repositories(first: $first) { nodes { languages(first: 100) { nodes { name } } } }
+				}
+			}`,
+			expected: graphql.Cost{Nodes: 100 + 100*100, Requests: 1 + 100, Points: 1},
+		},
+		{
+			name: "points round to the nearest hundred requests",
+			query: `{
+				owner(login: "a") {
+					repositories(first: 100) { nodes { languages(first: 100) { nodes { name } } } }
+					more: repositories(first: 60) { nodes { languages(first: 1) { nodes { name } } } }
+				}
+			}`,
+			expected: graphql.Cost{Nodes: 100 + 100*100 + 60 + 60, Requests: 1 + 100 + 1 + 60, Points: 2},
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.name, func() {
+			// Arrange
+			document, err := graphql.Parse(tt.query)
+			uts.Require().NoError(err)
+
+			// Act
+			cost, err := graphql.EstimateCost(document, "", tt.variables)
+
+			// Assert
+			uts.NoError(err)
+			uts.Equal(tt.expected, cost)
+		})
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
-	"net/url"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+	"github.com/abhisheksrocks/readme-studio/tracing"
 )
 
 type RequestHeader struct {
@@ -56,10 +61,22 @@ func makeAuthorizationHeader(token string) RequestHeader {
 type GraphQlRequestErrorMessage string
 
 const (
-	GraphQlRequestErrorUnknown          GraphQlRequestErrorMessage = "unknown error"
-	GraphQlRequestErrorInvalidEndpoint  GraphQlRequestErrorMessage = "couldn't parse endpoint"
-	GraphQlRequestErrorInvalidQueryData GraphQlRequestErrorMessage = "invalid query data"
-	GraphQlRequestErrorInvalidResponse  GraphQlRequestErrorMessage = "couldn't parse response"
+	GraphQlRequestErrorUnknown           GraphQlRequestErrorMessage = "unknown error"
+	GraphQlRequestErrorInvalidEndpoint   GraphQlRequestErrorMessage = "couldn't parse endpoint"
+	GraphQlRequestErrorInvalidQueryData  GraphQlRequestErrorMessage = "invalid query data"
+	GraphQlRequestErrorInvalidResponse   GraphQlRequestErrorMessage = "couldn't parse response"
+	GraphQlRequestErrorCanceled          GraphQlRequestErrorMessage = "request canceled"
+	GraphQlRequestErrorDeadlineExceeded  GraphQlRequestErrorMessage = "request deadline exceeded"
+	GraphQlRequestErrorAttemptTimeout    GraphQlRequestErrorMessage = "request attempt timed out"
+	GraphQlRequestErrorRateLimitBudget   GraphQlRequestErrorMessage = "rate limit budget exhausted"
+	GraphQlRequestErrorMissingData       GraphQlRequestErrorMessage = "no data returned"
+	GraphQlRequestErrorNotFound          GraphQlRequestErrorMessage = "not found"
+	GraphQlRequestErrorForbidden         GraphQlRequestErrorMessage = "forbidden"
+	GraphQlRequestErrorRateLimited       GraphQlRequestErrorMessage = "rate limited"
+	GraphQlRequestErrorQueryFailed       GraphQlRequestErrorMessage = "query failed"
+	GraphQlRequestErrorUnauthorized      GraphQlRequestErrorMessage = "unauthorized"
+	GraphQlRequestErrorResponseTooLarge  GraphQlRequestErrorMessage = "response too large"
+	GraphQlRequestErrorTruncatedResponse GraphQlRequestErrorMessage = "response body truncated"
 )
 
 type ErrorDataSource string
@@ -68,12 +85,32 @@ const (
 	ErrorDataSourceUnknown ErrorDataSource = "unknown"
 	ErrorDataSourceGithub  ErrorDataSource = "github"
 	ErrorDataSourceUs      ErrorDataSource = "us"
+	ErrorDataSourceContext ErrorDataSource = "context"
 )
 
+// ErrorData is the error every GitHub call fails with. Match it against
+// the Err sentinels with errors.Is, or get at its fields with errors.As.
+// URL points to GitHub's documentation when GitHub sent one, and Header is
+// the header of the failed response when there was one.
 type ErrorData struct {
-	Source  ErrorDataSource
-	Message string
-	URL     string
+	Source       ErrorDataSource
+	Message      string
+	URL          string
+	StatusCode   int                `json:",omitempty"`
+	RequestID    string             `json:",omitempty"`
+	Attempts     []ErrorDataAttempt `json:",omitempty"`
+	GithubErrors []GithubErrorModel `json:",omitempty"`
+	Header       http.Header        `json:"-"`
+	Cause        error              `json:"-"`
+}
+
+// ErrorDataAttempt describes one failed try of a retried request. Delay is
+// how long the client waited before the next try.
+type ErrorDataAttempt struct {
+	Source     ErrorDataSource
+	Message    string
+	StatusCode int           `json:",omitempty"`
+	Delay      time.Duration `json:",omitempty"`
 }
 
 type GithubError struct {
@@ -89,89 +126,404 @@ func (src GithubError) toErrorData() ErrorData {
 	}
 }
 
-func makeRequest(endpointURL string, query string, headers []RequestHeader, client *http.Client, result interface{}) *ErrorData {
-	queryMap := map[string]string{
-		"query": query,
+// GraphQlQuery is the request body sent to the GraphQL endpoint. Values
+// coming from callers must travel in Variables and never be spliced into
+// Query itself.
+type GraphQlQuery struct {
+	Query     string `json:"query"`
+	Variables any    `json:"variables,omitempty"`
+}
+
+// GraphQlClient sends queries to a GraphQL endpoint. Timeout bounds a
+// whole call, retries included, while AttemptTimeout bounds a single HTTP
+// round trip; zero disables either of them.
+type GraphQlClient struct {
+	Endpoint   string
+	HTTPClient *http.Client
+	// Middlewares run around every round trip, in order, with HTTPClient
+	// as the last stage.
+	Middlewares    []Middleware
+	Timeout        time.Duration
+	AttemptTimeout time.Duration
+	Retry          RetryPolicy
+	// RateLimit is optional and tracks a single token's budget. It is for
+	// clients sending one token themselves: with a TokenPool or a
+	// GithubAppAuth in Middlewares, budgets are tracked and guarded per
+	// token there instead, and RateLimit is best left nil, the way main
+	// does. With InjectRateLimit set, queries also ask for their own cost
+	// so the trackers know more than the headers tell.
+	RateLimit       *RateLimitTracker
+	InjectRateLimit bool
+	// Cache is optional. Successful responses are kept for the TTL found in
+	// CacheTTLs under the operation name, or for CacheTTL otherwise.
+	Cache     Cache
+	CacheTTL  time.Duration
+	CacheTTLs map[string]time.Duration
+	// CacheIdentity keeps apart the cache entries of clients that may see
+	// different data, see tokenIdentity.
+	CacheIdentity string
+	// AllowPartialData makes a response that holds both data and GraphQL
+	// errors a success, leaving the errors to the caller.
+	AllowPartialData bool
+	// MaxResponseBytes bounds the size of a response body, zero stands for
+	// DefaultMaxResponseBytes.
+	MaxResponseBytes int64
+	// Fragments are added to the queries spreading them, GithubFragments
+	// when nil.
+	Fragments *graphql.FragmentRegistry
+	// InFlight is optional. Queries sent while an identical one is still
+	// running wait for its response instead of being sent again.
+	InFlight *CallGroup
+	// Tracer is optional. Calls are recorded as spans, with their cache
+	// lookup, attempts and decoding as children, under the span ctx
+	// carries.
+	Tracer *tracing.Tracer
+	// Metrics is optional. Every attempt and cache lookup is counted in it.
+	Metrics *Metrics
+}
+
+func NewGraphQlClient(endpointURL string, client *http.Client, middlewares ...Middleware) *GraphQlClient {
+	return &GraphQlClient{
+		Endpoint:    endpointURL,
+		HTTPClient:  client,
+		Middlewares: middlewares,
 	}
-	queryRaw, err := json.Marshal(queryMap)
+}
+
+func makeRequest(endpointURL string, query string, variables any, headers []RequestHeader, client *http.Client, result interface{}) error {
+	return NewGraphQlClient(endpointURL, client, HeadersMiddleware(headers)).Do(context.Background(), query, variables, result)
+}
+
+func (c *GraphQlClient) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+func (c *GraphQlClient) fragments() *graphql.FragmentRegistry {
+	if c.Fragments == nil {
+		return GithubFragments
+	}
+	return c.Fragments
+}
+
+func (c *GraphQlClient) transport() http.RoundTripper {
+	return Chain(RoundTripperFunc(c.httpClient().Do), c.Middlewares...)
+}
+
+// Do sends query with variables and decodes the response into result. It
+// fails with an *ErrorData.
+func (c *GraphQlClient) Do(ctx context.Context, query string, variables any, result interface{}) error {
+	ctx, span := c.Tracer.Start(ctx, spanCall, tracing.SpanKindInternal)
+	span.SetAttributes(tracing.String(attributeOperation, operationName(query)))
+	err := c.share(ctx, query, variables, result).asError()
+	endSpan(span, err)
+	return err
+}
+
+// share sends query through InFlight, when the client has one, so
+// identical queries running at once share their call.
+func (c *GraphQlClient) share(ctx context.Context, query string, variables any, result interface{}) *ErrorData {
+	if c.InFlight == nil || !isIdempotentQuery(query) {
+		return c.do(ctx, query, variables, result)
+	}
+	key, ok := cacheKey(query, variables, c.CacheIdentity)
+	if !ok {
+		return c.do(ctx, query, variables, result)
+	}
+	return c.InFlight.do(ctx, key, func(ctx context.Context, body *json.RawMessage) *ErrorData {
+		return c.do(ctx, query, variables, body)
+	}, result)
+}
+
+func (c *GraphQlClient) do(ctx context.Context, query string, variables any, result interface{}) *ErrorData {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	query, err := c.fragments().Complete(query)
 	if err != nil {
 		return &ErrorData{
 			Source:  ErrorDataSourceUs,
 			Message: string(GraphQlRequestErrorInvalidQueryData),
+			Cause:   err,
+		}
+	}
+	estimatedCost := 1
+	if cost, err := estimateQueryCost(query, variables); err == nil {
+		c.callSpan(ctx).SetAttributes(tracing.Int(attributeEstimatedCost, cost.Points))
+		if cost.Points > estimatedCost {
+			estimatedCost = cost.Points
 		}
 	}
-	_, err = url.Parse(endpointURL)
+	key, cacheable := c.cacheKey(query, variables)
+	if cacheable {
+		_, lookup := c.Tracer.Start(ctx, spanCacheGet, tracing.SpanKindInternal)
+		data, ok := c.Cache.Get(key)
+		hit := ok && json.Unmarshal(data, result) == nil
+		lookup.SetAttributes(tracing.Bool(attributeCacheHit, hit))
+		lookup.End()
+		c.Metrics.observeCacheLookup(hit)
+		if hit {
+			return nil
+		}
+	}
+	sentQuery := query
+	if c.InjectRateLimit {
+		sentQuery = injectIntoOperation(query, rateLimitSelection)
+	}
+	queryRaw, err := json.Marshal(GraphQlQuery{
+		Query:     sentQuery,
+		Variables: variables,
+	})
 	if err != nil {
 		return &ErrorData{
 			Source:  ErrorDataSourceUs,
-			Message: string(GraphQlRequestErrorInvalidEndpoint),
+			Message: string(GraphQlRequestErrorInvalidQueryData),
 		}
 	}
-
-	requestData, err := http.NewRequest(http.MethodPost, endpointURL, bytes.NewBuffer(queryRaw))
-	if err != nil {
+	if err := ValidateEndpoint(c.Endpoint); err != nil {
 		return &ErrorData{
 			Source:  ErrorDataSourceUs,
-			Message: (err.Error()),
+			Message: string(GraphQlRequestErrorInvalidEndpoint),
+			Cause:   err,
 		}
 	}
-	for _, head := range headers {
-		if empty(head.key) {
-			return &ErrorData{
-				Source:  ErrorDataSourceUs,
-				Message: string(RequestHeaderErrorEmptyKey),
-			}
+	var body []byte
+	partial := false
+	attempts := 0
+	errData := c.withRetries(ctx, query, func(info *attemptInfo) *ErrorData {
+		attempts++
+		attemptCtx, span := c.Tracer.Start(ctx, spanAttempt, tracing.SpanKindClient)
+		span.SetAttributes(tracing.Int(attributeAttempt, attempts))
+		info.keepBody = cacheable
+		started := time.Now()
+		attemptError := c.attempt(attemptCtx, queryRaw, estimatedCost, result, info)
+		c.Metrics.observeUpstream(operationName(query), attemptError, time.Since(started))
+		body, partial = info.body, info.partial
+		if attemptError != nil && info.header != nil {
+			attemptError.StatusCode = info.statusCode
+			attemptError.RequestID = info.header.Get(githubRequestIDHeader)
+			attemptError.Header = info.header
 		}
-		if empty(head.value) {
-			return &ErrorData{
-				Source:  ErrorDataSourceUs,
-				Message: string(RequestHeaderErrorEmptyValue),
-			}
+		if info.statusCode != 0 {
+			span.SetAttributes(tracing.Int(attributeStatusCode, info.statusCode))
+		}
+		if info.cost > 0 {
+			span.SetAttributes(tracing.Int(attributeCost, info.cost))
+			c.callSpan(ctx).SetAttributes(tracing.Int(attributeCost, info.cost))
+		}
+		endSpan(span, attemptError.asError())
+		return attemptError
+	})
+	if errData == nil && cacheable && !partial {
+		c.Cache.Set(key, body, c.cacheTTL(query))
+	}
+	return errData
+}
+
+func (c *GraphQlClient) cacheTTL(query string) time.Duration {
+	if ttl, ok := c.CacheTTLs[operationName(query)]; ok {
+		return ttl
+	}
+	return c.CacheTTL
+}
+
+func (c *GraphQlClient) cacheKey(query string, variables any) (string, bool) {
+	if c.Cache == nil || c.cacheTTL(query) <= 0 || !isIdempotentQuery(query) {
+		return "", false
+	}
+	return cacheKey(query, variables, c.CacheIdentity)
+}
+
+// attemptInfo carries what a single round trip learned about the response,
+// for the retry policy to decide on the next step.
+type attemptInfo struct {
+	statusCode int
+	header     http.Header
+	// body is only kept when keepBody asks for it
+	body     []byte
+	keepBody bool
+	// partial is set when the response carried GraphQL errors next to data
+	partial bool
+	// cost is what GitHub charged, when the rateLimit field was injected
+	cost int
+}
+
+// attempt sends queryRaw once. estimatedCost is held on the rate limit
+// budget until the response is in, and goes down with the request for the
+// stage authorizing it to hold on the budget of its token.
+func (c *GraphQlClient) attempt(ctx context.Context, queryRaw []byte, estimatedCost int, result interface{}, info *attemptInfo) *ErrorData {
+	if c.RateLimit != nil {
+		if budgetError := c.RateLimit.reserve(ctx, estimatedCost); budgetError != nil {
+			return budgetError
 		}
-		requestData.Header.Add(head.key, head.value)
+		defer c.RateLimit.settle(estimatedCost)
 	}
-	response, err := client.Do(requestData)
+
+	attemptCtx := ctx
+	if c.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.AttemptTimeout)
+		defer cancel()
+	}
+
+	claim := &rateLimitClaim{cost: estimatedCost}
+	requestData, err := http.NewRequestWithContext(withRateLimitClaim(attemptCtx, claim), http.MethodPost, c.Endpoint, bytes.NewBuffer(queryRaw))
 	if err != nil {
-		// return err
 		return &ErrorData{
-			Source:  ErrorDataSourceUnknown,
+			Source:  ErrorDataSourceUs,
 			Message: (err.Error()),
+			Cause:   err,
 		}
 	}
-	defer response.Body.Close()
-	data, err := io.ReadAll(response.Body)
+	response, err := c.transport().RoundTrip(requestData)
 	if err != nil {
-		// return errors.New(GraphQlRequestErrorInvalidResponse)
-		return &ErrorData{
-			Source:  ErrorDataSourceUs,
-			Message: string(GraphQlRequestErrorInvalidResponse),
+		if contextError := contextErrorData(ctx, attemptCtx); contextError != nil {
+			return contextError
 		}
+		return roundTripErrorData(err)
 	}
+	defer response.Body.Close()
+	info.statusCode = response.StatusCode
+	info.header = response.Header
+	if c.RateLimit != nil {
+		c.RateLimit.observeHeader(response.Header, time.Now())
+	}
+	body := newBoundedReader(response.Body, c.maxResponseBytes())
 	if response.StatusCode >= http.StatusOK && response.StatusCode < 400 {
-		err = json.Unmarshal(data, &result)
+		sink := responseSink{
+			client:   c,
+			result:   result,
+			info:     info,
+			claim:    claim,
+			keepBody: info.keepBody,
+		}
+		_, decode := c.Tracer.Start(ctx, spanDecode, tracing.SpanKindInternal)
+		err := json.NewDecoder(body).Decode(&sink)
+		decode.End()
 		if err != nil {
-			return &ErrorData{
-				Source:  ErrorDataSourceUs,
-				Message: string(GraphQlRequestErrorInvalidResponse),
+			if contextError := contextErrorData(ctx, attemptCtx); contextError != nil {
+				return contextError
 			}
+			return decodeErrorData(err)
 		}
-		return nil
+		return sink.errData
 	}
 	var possibleGithubError GithubError
-	err = json.Unmarshal(data, &possibleGithubError)
+	err = json.NewDecoder(body).Decode(&possibleGithubError)
+	if errors.Is(err, io.EOF) {
+		// Nothing but the status to go by.
+		possibleGithubError.Message, err = http.StatusText(response.StatusCode), nil
+	}
 	if err != nil {
+		if contextError := contextErrorData(ctx, attemptCtx); contextError != nil {
+			return contextError
+		}
+		return decodeErrorData(err)
+	}
+	toReturn := possibleGithubError.toErrorData()
+	return &toReturn
+}
+
+func (c *GraphQlClient) maxResponseBytes() int64 {
+	if c.MaxResponseBytes <= 0 {
+		return DefaultMaxResponseBytes
+	}
+	return c.MaxResponseBytes
+}
+
+// roundTripErrorData describes a round trip that failed without a
+// response. Stages may fail with an *ErrorData of their own, which is kept.
+func roundTripErrorData(err error) *ErrorData {
+	var errData *ErrorData
+	if errors.As(err, &errData) {
+		return errData
+	}
+	var headerError RequestHeaderError
+	if errors.As(err, &headerError) {
 		return &ErrorData{
 			Source:  ErrorDataSourceUs,
-			Message: string(GraphQlRequestErrorInvalidResponse),
+			Message: string(headerError),
+			Cause:   err,
 		}
 	}
-	toReturn := possibleGithubError.toErrorData()
-	if notEmpty(string(toReturn.Message)) {
+	return &ErrorData{
+		Source:  ErrorDataSourceUnknown,
+		Message: (err.Error()),
+		Cause:   err,
+	}
+}
+
+// contextErrorData tells a caller cancellation or an expired deadline apart
+// from a network failure. ctx is the caller's context and attemptCtx the
+// one derived from it for a single round trip.
+func contextErrorData(ctx context.Context, attemptCtx context.Context) *ErrorData {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &ErrorData{
+			Source:  ErrorDataSourceContext,
+			Message: string(GraphQlRequestErrorCanceled),
+			Cause:   ctx.Err(),
+		}
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
 		return &ErrorData{
-			Source:  ErrorDataSourceGithub,
-			Message: toReturn.Message,
+			Source:  ErrorDataSourceContext,
+			Message: string(GraphQlRequestErrorDeadlineExceeded),
+			Cause:   ctx.Err(),
+		}
+	case errors.Is(attemptCtx.Err(), context.DeadlineExceeded):
+		return &ErrorData{
+			Source:  ErrorDataSourceContext,
+			Message: string(GraphQlRequestErrorAttemptTimeout),
+			Cause:   attemptCtx.Err(),
 		}
 	}
-	return &toReturn
-	// return errors.New(string(data))
+	return nil
+}
+
+// GithubErrorType is the "type" GitHub puts on errors of a GraphQL response.
+type GithubErrorType string
+
+const (
+	GithubErrorTypeNotFound    GithubErrorType = "NOT_FOUND"
+	GithubErrorTypeForbidden   GithubErrorType = "FORBIDDEN"
+	GithubErrorTypeRateLimited GithubErrorType = "RATE_LIMITED"
+)
+
+// graphQlErrorData turns the errors of a 2xx GraphQL response into an
+// ErrorData, unless partial data is allowed and some data came back.
+func (c *GraphQlClient) graphQlErrorData(githubErrors []GithubErrorModel, hasData bool, info *attemptInfo) *ErrorData {
+	if len(githubErrors) == 0 {
+		return nil
+	}
+	info.partial = true
+	if c.AllowPartialData && hasData {
+		return nil
+	}
+	return &ErrorData{
+		Source:       ErrorDataSourceGithub,
+		Message:      string(classifyGithubErrors(githubErrors)),
+		GithubErrors: githubErrors,
+	}
+}
+
+// classifyGithubErrors names a failed response after its most pressing
+// error: rate limiting first since it is worth retrying, then access.
+func classifyGithubErrors(githubErrors []GithubErrorModel) GraphQlRequestErrorMessage {
+	types := map[GithubErrorType]bool{}
+	for _, githubError := range githubErrors {
+		types[GithubErrorType(githubError.Type)] = true
+	}
+	switch {
+	case types[GithubErrorTypeRateLimited]:
+		return GraphQlRequestErrorRateLimited
+	case types[GithubErrorTypeForbidden]:
+		return GraphQlRequestErrorForbidden
+	case types[GithubErrorTypeNotFound]:
+		return GraphQlRequestErrorNotFound
+	}
+	return GraphQlRequestErrorQueryFailed
 }
@@ -0,0 +1,295 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestGraphQlClientSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestGraphQlClientSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestGraphQlClientSuite))
+}
+
+type graphQlTestResult = main.GithubResultModel[struct {
+	Viewer struct {
+		Login string `json:"login"`
+	} `json:"viewer"`
+}]
+
+const (
+	testQuery    = "query Viewer($login: String!) { viewer { login } }"
+	testLogin    = "octocat"
+	testResponse = `{"data":{"viewer":{"login":"octocat"}}}`
+)
+
+// errorData digs the *main.ErrorData out of err, nil when there is none.
+func errorData(err error) *main.ErrorData {
+	var errData *main.ErrorData
+	errors.As(err, &errData)
+	return errData
+}
+
+// blockingHandler never answers, it only returns once the client goes away.
+func blockingHandler(w http.ResponseWriter, r *http.Request) {
+	io.ReadAll(r.Body)
+	<-r.Context().Done()
+}
+
+func (uts *UnitTestGraphQlClientSuite) TestDo() {
+
+	var tests = []struct {
+		testName    string
+		handler     http.HandlerFunc
+		arrangeFunc func(t *testing.T, c *main.GraphQlClient) (context.Context, context.CancelFunc)
+		assertFunc  func(t *testing.T, result graphQlTestResult, err *main.ErrorData)
+	}{
+		{
+			testName: "sends variables next to query",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query     string            `json:"query"`
+					Variables map[string]string `json:"variables"`
+				}
+				raw, _ := io.ReadAll(r.Body)
+				if json.Unmarshal(raw, &body) != nil || body.Query != testQuery || body.Variables["login"] != testLogin {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"message":"unexpected body"}`))
+					return
+				}
+				w.Write([]byte(testResponse))
+			},
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+			},
+		},
+		{
+			testName: "canceled context",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(testResponse))
+			},
+			arrangeFunc: func(t *testing.T, c *main.GraphQlClient) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(main.ErrorDataSourceContext, err.Source)
+				asserts.Equal(string(main.GraphQlRequestErrorCanceled), err.Message)
+			},
+		},
+		{
+			testName: "overall timeout",
+			handler:  blockingHandler,
+			arrangeFunc: func(t *testing.T, c *main.GraphQlClient) (context.Context, context.CancelFunc) {
+				c.Timeout = 20 * time.Millisecond
+				return context.WithCancel(context.Background())
+			},
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(main.ErrorDataSourceContext, err.Source)
+				asserts.Equal(string(main.GraphQlRequestErrorDeadlineExceeded), err.Message)
+			},
+		},
+		{
+			testName: "attempt timeout",
+			handler:  blockingHandler,
+			arrangeFunc: func(t *testing.T, c *main.GraphQlClient) (context.Context, context.CancelFunc) {
+				c.Timeout = time.Minute
+				c.AttemptTimeout = 20 * time.Millisecond
+				return context.WithCancel(context.Background())
+			},
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(main.ErrorDataSourceContext, err.Source)
+				asserts.Equal(string(main.GraphQlRequestErrorAttemptTimeout), err.Message)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := httptest.NewServer(v.handler)
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if v.arrangeFunc != nil {
+				ctx, cancel = v.arrangeFunc(t, client)
+			}
+			defer cancel()
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(ctx, testQuery, map[string]string{"login": testLogin}, &result)
+
+			// Assert
+			v.assertFunc(t, result, errorData(err))
+		})
+	}
+}
+
+func (uts *UnitTestGraphQlClientSuite) TestDoCompletesFragments() {
+	const viewerFragment = "fragment Viewer on User { login }"
+	tests := []struct {
+		testName      string
+		query         string
+		expectedQuery string
+		expectedErr   string
+	}{
+		{
+			testName:      "adds registered fragments",
+			query:         "query Viewer { viewer { ...Viewer } }",
+			expectedQuery: "query Viewer { viewer { ...Viewer } }\n\n" + viewerFragment,
+		},
+		{
+			testName:      "leaves queries without spreads alone",
+			query:         testQuery,
+			expectedQuery: testQuery,
+		},
+		{
+			testName:    "refuses undefined fragments",
+			query:       "query Viewer { viewer { ...Missing } }",
+			expectedErr: string(main.GraphQlRequestErrorInvalidQueryData),
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.testName, func() {
+			asserts := assert.New(uts.T())
+
+			// Arrange
+			var sentQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body main.GraphQlQuery
+				raw, _ := io.ReadAll(r.Body)
+				json.Unmarshal(raw, &body)
+				sentQuery = body.Query
+				w.Write([]byte(testResponse))
+			}))
+			defer server.Close()
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.Fragments = graphql.MustFragmentRegistry(viewerFragment)
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(context.Background(), tt.query, map[string]string{"login": testLogin}, &result)
+
+			// Assert
+			if tt.expectedErr != "" {
+				errData := errorData(err)
+				uts.Require().NotNil(errData)
+				asserts.Equal(main.ErrorDataSourceUs, errData.Source)
+				asserts.Equal(tt.expectedErr, errData.Message)
+				asserts.Empty(sentQuery, "nothing is sent")
+				return
+			}
+			asserts.Nil(err)
+			asserts.Equal(tt.expectedQuery, sentQuery)
+		})
+	}
+}
+
+func (uts *UnitTestGraphQlClientSuite) TestGraphQlErrors() {
+
+	const (
+		notFoundResponse  = `{"data":{"viewer":null},"errors":[{"type":"NOT_FOUND","path":["viewer"],"message":"Could not resolve to a User."}]}`
+		forbiddenResponse = `{"data":{"viewer":{"login":"octocat"},"secret":null},` +
+			`"errors":[{"type":"FORBIDDEN","path":["secret",0,"name"],"message":"Resource not accessible."}]}`
+	)
+
+	var tests = []struct {
+		testName         string
+		response         string
+		allowPartialData bool
+		assertFunc       func(t *testing.T, result graphQlTestResult, err *main.ErrorData)
+	}{
+		{
+			testName: "not found",
+			response: notFoundResponse,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(main.ErrorDataSourceGithub, err.Source)
+				asserts.Equal(string(main.GraphQlRequestErrorNotFound), err.Message)
+				asserts.Len(err.GithubErrors, 1)
+			},
+		},
+		{
+			testName:         "not found without data even if partial data is allowed",
+			response:         notFoundResponse,
+			allowPartialData: true,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(string(main.GraphQlRequestErrorNotFound), err.Message)
+			},
+		},
+		{
+			testName: "forbidden",
+			response: forbiddenResponse,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.Equal(string(main.GraphQlRequestErrorForbidden), err.Message)
+				asserts.Equal([]any{"secret", float64(0), "name"}, err.GithubErrors[0].Path)
+			},
+		},
+		{
+			testName:         "partial data allowed",
+			response:         forbiddenResponse,
+			allowPartialData: true,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(v.response))
+			}))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.AllowPartialData = v.allowPartialData
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(context.Background(), testQuery, nil, &result)
+
+			// Assert
+			v.assertFunc(t, result, errorData(err))
+		})
+	}
+}
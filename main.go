@@ -1,15 +1,53 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/tracing"
 )
 
+// APIEndpoint is the GraphQL endpoint of github.com, used unless
+// GITHUB_API_URL points somewhere else.
 const APIEndpoint = "https://api.github.com/graphql"
 
+// UserAgent identifies us to GitHub, which refuses requests without one.
+const UserAgent = "readme-studio"
+
+const (
+	RequestTimeout        = 30 * time.Second
+	RequestAttemptTimeout = 10 * time.Second
+)
+
+// RateLimitReserve is the part of the hourly budget we never spend, so a
+// token shared with other tools isn't drained by us alone.
+const RateLimitReserve = 50
+
+const MemoryCacheEntries = 512
+
+const (
+	CacheDirEnvKey           = "README_STUDIO_CACHE_DIR"
+	CacheDirEnvKeyHelperText = "Directory where GitHub responses are cached across restarts. " +
+		"Responses are only cached in memory when it is not set."
+	CacheMaxBytesEnvKey           = "README_STUDIO_CACHE_MAX_BYTES"
+	CacheMaxBytesEnvKeyHelperText = "Size in bytes the cache directory is trimmed down to."
+	DefaultCacheMaxBytes          = 64 << 20
+)
+
+const (
+	MaxResponseBytesEnvKey           = "README_STUDIO_MAX_RESPONSE_BYTES"
+	MaxResponseBytesEnvKeyHelperText = "Largest response in bytes accepted from GitHub."
+)
+
 const (
 	EnvFile        = ".env"
 	ExampleEnvFile = ".env.example"
@@ -20,17 +58,22 @@ const (
 	GithubTokenEnvKeyHelperText = "This is used as Github Authentication Token. It is required to access github's servers." +
 		"\n\n\tYou can create yours at:-" +
 		"\n\thttps://docs.github.com/en/authentication/keeping-your-account-and-data-secure/creating-a-personal-access-token" +
-		"\n\n\tWhile generating your token, no permissions or scopes are required.\n"
+		"\n\n\tWhile generating your token, no permissions or scopes are required." +
+		"\n\n\tSeveral tokens can be given as a comma separated list, or as" +
+		"\n\t" + GithubTokenEnvKey + "_2, " + GithubTokenEnvKey + "_3 and so on.\n"
 )
 
-func commonRequestHeaders(readEnv *ReadEnv) []RequestHeader {
+func commonRequestHeaders() []RequestHeader {
 	return []RequestHeader{
 		makeDefaultContentTypeHeader(),
-		makeAuthorizationHeader(readEnv.KeyVal.GetCacheValue()),
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate-queries" {
+		os.Exit(validateQueries(os.Stdout))
+	}
+
 	// Get current location path
 	location, err := os.Getwd()
 	if err != nil {
@@ -48,26 +91,36 @@ func main() {
 	}
 
 	readEnv, err := NewReadEnv(envFileLocation, exampleEnvFileLocation, keyData, new(DefReadEnvEnvironment))
+	if errors.Is(err, ReadEnvErrorValueNotFound) {
+		// Running as a GitHub App needs no token.
+		appKeyData := EnvKey{
+			Key:     GithubAppIDEnvKey,
+			UsedFor: GithubAppIDEnvKeyHelperText,
+		}
+		if appReadEnv, appErr := NewReadEnv(envFileLocation, exampleEnvFileLocation, appKeyData, new(DefReadEnvEnvironment)); appErr == nil {
+			readEnv, err = appReadEnv, nil
+		}
+	}
 	if err != nil {
-		switch err.Error() {
-		case string(ReadEnvErrorExampleFileNotFound):
-			log.Fatalln("\n\tCouldn't find \"" + readEnv.ExampleFilePath + "\"" +
+		switch {
+		case errors.Is(err, ReadEnvErrorExampleFileNotFound):
+			log.Fatalln("\n\tCouldn't find \"" + exampleEnvFileLocation + "\"" +
 				"\n\n\tTIP: It is not mandatory to have an example file. So you can skip this." +
 				"\n\tBut it is a good idea to always provide one for ease of use\n")
 
-		case string(ReadEnvErrorFileNotFound):
-			log.Fatalln("\n\tCouldn't load \"" + readEnv.FilePath + "\"" +
+		case errors.Is(err, ReadEnvErrorFileNotFound):
+			log.Fatalln("\n\tCouldn't load \"" + envFileLocation + "\"" +
 				"\n\n\tTip: You don't necessarily have to pass this value, if the value" +
 				"\n\tis already present in system environment variables\n")
 
-		case string(ReadEnvErrorValueNotFound):
-			defPrint := "\n\tCouldn't read value for key \"" + readEnv.KeyVal.KeyData.Key + "\" from environment variables"
-			if notEmpty(readEnv.KeyVal.KeyData.UsedFor) {
+		case errors.Is(err, ReadEnvErrorValueNotFound):
+			defPrint := "\n\tCouldn't read value for key \"" + keyData.Key + "\" from environment variables"
+			if notEmpty(keyData.UsedFor) {
 				defPrint += "\n\tHere's something that may explain its use:" +
-					"\n\n\t" + readEnv.KeyVal.KeyData.UsedFor
+					"\n\n\t" + keyData.UsedFor
 			}
-			if notEmpty(readEnv.ExampleFilePath) {
-				defPrint += "\n\tUse \"" + readEnv.ExampleFilePath + "\" file for reference"
+			if notEmpty(exampleEnvFileLocation) {
+				defPrint += "\n\tUse \"" + exampleEnvFileLocation + "\" file for reference"
 			}
 			log.Fatalln(defPrint)
 
@@ -79,17 +132,267 @@ func main() {
 	username := "abhisheksrocks"
 	reponame := "async_button"
 
-	var queryResult GithubResultModel[GithubRepositoryCardModel]
-	query := queryResult.Data.makeQuery(reponame, username)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	endpoints := newGithubEndpoints(readEnv)
+	httpClient := newHTTPClient(readEnv)
+	authMiddleware, cacheIdentity, tokenPool, appAuth := newAuth(readEnv, endpoints, httpClient)
+
+	client := NewGraphQlClient(endpoints.GraphQL, httpClient,
+		UserAgentMiddleware(UserAgent),
+		RequestIDMiddleware(),
+		HeadersMiddleware(commonRequestHeaders()),
+		authMiddleware,
+	)
+	client.Timeout = RequestTimeout
+	client.AttemptTimeout = RequestAttemptTimeout
+	client.Retry = DefaultRetryPolicy
+	// Budgets are tracked per token by the pool or the app, so the client
+	// itself has no RateLimit.
+	client.InjectRateLimit = true
+	client.Cache = newCache(readEnv)
+	client.CacheTTLs = GithubModelCacheTTLs
+	client.CacheIdentity = cacheIdentity
+	client.MaxResponseBytes = maxResponseBytes(readEnv)
+	client.InFlight = NewCallGroup()
+	client.Tracer = newTracer(readEnv)
+	defer shutdownTracer(client.Tracer)
+	clientMetrics, metricsStopped := newMetrics(ctx, readEnv, client.Tracer)
+	client.Metrics = clientMetrics
+	client.Metrics.WatchTokenPool(tokenPool)
+	client.Metrics.WatchGithubApp(appAuth)
 
-	returnedError := makeRequest(APIEndpoint, query, commonRequestHeaders(readEnv),
-		new(http.Client), &queryResult)
+	cardCtx, cardSpan := client.Tracer.Start(ctx, spanCard, tracing.SpanKindInternal)
+	cardSpan.SetAttributes(tracing.String(attributeCardType, CardTypeRepository))
+	queryResult, returnedError := FetchRepositoryCard(cardCtx, client, reponame, username)
 
+	_, renderSpan := client.Tracer.Start(cardCtx, spanCardRender, tracing.SpanKindInternal)
+	renderStarted := time.Now()
 	if returnedError != nil {
 		res, _ := json.MarshalIndent(returnedError, "", "    ")
 		log.Println(string(res))
+		if errors.Is(returnedError, ErrUnauthorized) && tokenPool != nil {
+			log.Print("\n\tGithub didn't accept the tokens in \"" + GithubTokenEnvKey + "\"" +
+				"\n\n\t" + GithubTokenEnvKeyHelperText)
+		}
 	} else {
 		res, _ := json.MarshalIndent(queryResult, "", "    ")
 		log.Println(string(res))
+		client.Metrics.CardServed(CardTypeRepository)
+	}
+	client.Metrics.ObserveRender(CardTypeRepository, time.Since(renderStarted))
+	renderSpan.End()
+	endSpan(cardSpan, returnedError)
+	var tokenStatuses []TokenStatus
+	if tokenPool != nil {
+		tokenStatuses = tokenPool.Status()
+	}
+	if appAuth != nil {
+		tokenStatuses = appAuth.Status()
+	}
+	for _, status := range tokenStatuses {
+		if status.BudgetKnown {
+			log.Printf("rate limit of token %s: %d/%d remaining, last query cost %d, resets at %s",
+				status.Identity, status.Budget.Remaining, status.Budget.Limit, status.Budget.Cost, status.Budget.Reset.Format(time.RFC3339))
+		}
+	}
+	if metricsStopped != nil {
+		if err := <-metricsStopped; err != nil {
+			log.Println("Metrics listener stopped: " + err.Error())
+		}
+	}
+}
+
+// newAuth picks how requests are authorized: as a GitHub App when readEnv
+// was opened on its ID, with the pool of personal tokens otherwise. Only
+// one of the pool and the app is returned, the other is nil. Either way
+// the budget of every token is tracked.
+func newAuth(readEnv *ReadEnv, endpoints GithubEndpoints, httpClient *http.Client) (Middleware, string, *TokenPool, *GithubAppAuth) {
+	if readEnv.KeyVal.KeyData.Key == GithubAppIDEnvKey {
+		appAuth := newGithubAppAuth(readEnv)
+		appAuth.RESTEndpoint = endpoints.REST
+		appAuth.HTTPClient = httpClient
+		appAuth.RateLimit = NewRateLimitTracker(RateLimitReserve, RateLimitActionRefuse)
+		return appAuth.Middleware(), appAuth.Identity(), nil, appAuth
+	}
+
+	tokens, err := readEnv.LookupAll(readEnv.KeyVal.KeyData)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	tokenPool := NewTokenPool(tokens, RateLimitReserve, DefaultTokenQuarantine)
+	return tokenPool.Middleware(), tokenPool.Identity(), tokenPool, nil
+}
+
+func newGithubAppAuth(readEnv *ReadEnv) *GithubAppAuth {
+	appID := readEnv.KeyVal.GetCacheValue()
+	privateKey, err := readEnv.Lookup(EnvKey{
+		Key:     GithubAppPrivateKeyEnvKey,
+		UsedFor: GithubAppPrivateKeyEnvKeyHelperText,
+	})
+	if err != nil {
+		log.Fatalln("\n\tCouldn't read value for key \"" + GithubAppPrivateKeyEnvKey + "\" from environment variables" +
+			"\n\tHere's something that may explain its use:" +
+			"\n\n\t" + GithubAppPrivateKeyEnvKeyHelperText)
+	}
+	// Single line values can't hold the PEM newlines, so they come escaped.
+	privateKeyPEM := []byte(strings.ReplaceAll(privateKey, `\n`, "\n"))
+	if !strings.Contains(privateKey, "-----BEGIN") {
+		if privateKeyPEM, err = os.ReadFile(privateKey); err != nil {
+			log.Fatalln("\n\tCouldn't read the GitHub App private key from \"" + privateKey + "\"" +
+				"\n\n\t" + err.Error())
+		}
+	}
+	installationID, _ := readEnv.Lookup(EnvKey{
+		Key:     GithubAppInstallationIDEnvKey,
+		UsedFor: GithubAppInstallationIDEnvKeyHelperText,
+	})
+
+	appAuth, err := NewGithubAppAuth(appID, installationID, privateKeyPEM)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't use \"" + GithubAppPrivateKeyEnvKey + "\"" +
+			"\n\n\t" + err.Error())
+	}
+	return appAuth
+}
+
+// newGithubEndpoints finds the instance to talk to and checks its URLs
+// before anything is sent.
+func newGithubEndpoints(readEnv *ReadEnv) GithubEndpoints {
+	apiURL, _ := readEnv.Lookup(EnvKey{
+		Key:     GithubAPIURLEnvKey,
+		UsedFor: GithubAPIURLEnvKeyHelperText,
+	})
+	endpoints, err := ParseGithubAPIURL(apiURL)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't use \"" + apiURL + "\" from \"" + GithubAPIURLEnvKey + "\"" +
+			"\n\n\t" + err.Error() +
+			"\n\n\t" + GithubAPIURLEnvKeyHelperText)
+	}
+
+	if graphQLURL, err := readEnv.Lookup(EnvKey{
+		Key:     GithubGraphQLURLEnvKey,
+		UsedFor: GithubGraphQLURLEnvKeyHelperText,
+	}); err == nil {
+		if err := ValidateEndpoint(graphQLURL); err != nil {
+			log.Fatalln("\n\tCouldn't use \"" + graphQLURL + "\" from \"" + GithubGraphQLURLEnvKey + "\"" +
+				"\n\n\t" + err.Error())
+		}
+		endpoints.GraphQL = graphQLURL
+	}
+	return endpoints
+}
+
+// newHTTPClient builds the client every GitHub request goes through, with
+// the proxy, TLS and connection settings of the environment.
+func newHTTPClient(readEnv *ReadEnv) *http.Client {
+	config, err := LoadTransportConfig(readEnv)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't read the connection settings from environment variables" +
+			"\n\n\t" + err.Error())
+	}
+	httpClient, err := NewHTTPClient(config)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't set up connections to GitHub" +
+			"\n\n\t" + err.Error() +
+			"\n\n\tCheck \"" + HTTPSProxyEnvKey + "\", \"" + GithubCABundleEnvKey + "\", \"" +
+			GithubClientCertEnvKey + "\" and \"" + GithubClientKeyEnvKey + "\"")
+	}
+	return httpClient
+}
+
+// newTracer sets up tracing when an exporter is configured, and returns
+// nil, which traces nothing, otherwise.
+func newTracer(readEnv *ReadEnv) *tracing.Tracer {
+	exporterName, err := readEnv.Lookup(EnvKey{
+		Key:     TraceExporterEnvKey,
+		UsedFor: TraceExporterEnvKeyHelperText,
+	})
+	if err != nil {
+		return nil
+	}
+	endpoint, _ := readEnv.Lookup(EnvKey{
+		Key:     OTLPEndpointEnvKey,
+		UsedFor: OTLPEndpointEnvKeyHelperText,
+	})
+	exporter, err := NewTraceExporter(exporterName, endpoint)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't use \"" + exporterName + "\" from \"" + TraceExporterEnvKey + "\"" +
+			"\n\n\t" + TraceExporterEnvKeyHelperText)
+	}
+	return tracing.NewTracer(exporter)
+}
+
+// newMetrics starts the Prometheus listener when an address is configured.
+// The channel tells when the listener stopped, which it does once ctx is
+// done; both are nil without a listener.
+func newMetrics(ctx context.Context, readEnv *ReadEnv, tracer *tracing.Tracer) (*Metrics, <-chan error) {
+	addr, err := readEnv.Lookup(EnvKey{
+		Key:     MetricsAddrEnvKey,
+		UsedFor: MetricsAddrEnvKeyHelperText,
+	})
+	if err != nil {
+		return nil, nil
+	}
+	clientMetrics := NewMetrics()
+	listening, stopped, err := ServeMetrics(ctx, addr, clientMetrics, tracer)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't listen on \"" + addr + "\" from \"" + MetricsAddrEnvKey + "\"" +
+			"\n\n\t" + err.Error())
+	}
+	log.Println("Serving metrics on http://" + listening.String() + MetricsPath + " until interrupted")
+	return clientMetrics, stopped
+}
+
+// shutdownTracer exports the spans still held before the program exits.
+func shutdownTracer(tracer *tracing.Tracer) {
+	ctx, cancel := context.WithTimeout(context.Background(), RequestTimeout)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		log.Println("Couldn't export traces: " + err.Error())
+	}
+}
+
+func maxResponseBytes(readEnv *ReadEnv) int64 {
+	value, err := readEnv.Lookup(EnvKey{
+		Key:     MaxResponseBytesEnvKey,
+		UsedFor: MaxResponseBytesEnvKeyHelperText,
+	})
+	if err != nil {
+		return DefaultMaxResponseBytes
+	}
+	maxBytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxBytes <= 0 {
+		log.Fatalln("\n\tCouldn't parse \"" + MaxResponseBytesEnvKey + "\" as a number of bytes")
+	}
+	return maxBytes
+}
+
+func newCache(readEnv *ReadEnv) Cache {
+	cacheDir, err := readEnv.Lookup(EnvKey{
+		Key:     CacheDirEnvKey,
+		UsedFor: CacheDirEnvKeyHelperText,
+	})
+	if err != nil {
+		return NewMemoryCache(MemoryCacheEntries)
+	}
+
+	var maxBytes int64 = DefaultCacheMaxBytes
+	if value, err := readEnv.Lookup(EnvKey{
+		Key:     CacheMaxBytesEnvKey,
+		UsedFor: CacheMaxBytesEnvKeyHelperText,
+	}); err == nil {
+		maxBytes, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Fatalln("\n\tCouldn't parse \"" + CacheMaxBytesEnvKey + "\" as a number of bytes")
+		}
+	}
+
+	cache, err := NewFileCache(cacheDir, maxBytes)
+	if err != nil {
+		log.Fatalln("\n\tCouldn't use \"" + cacheDir + "\" as cache directory" +
+			"\n\n\t" + err.Error() + "\n")
 	}
+	return cache
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/metrics"
+	"github.com/abhisheksrocks/readme-studio/tracing"
+)
+
+const (
+	MetricsAddrEnvKey           = "README_STUDIO_METRICS_ADDR"
+	MetricsAddrEnvKeyHelperText = "Address the Prometheus listener binds to, as :9090 or 127.0.0.1:9090. " +
+		"Metrics are served at " + MetricsPath + " and readme-studio keeps serving them until interrupted."
+)
+
+// MetricsPath is where the listener serves metrics.
+const MetricsPath = "/metrics"
+
+// metricsNoError is the error_source of upstream requests that succeeded.
+const metricsNoError = "none"
+
+// Metrics is what readme-studio tells Prometheus about its upstream calls
+// and the cards it serves. A nil *Metrics records nothing.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	upstreamRequests *metrics.Counter
+	upstreamDuration *metrics.Histogram
+	cacheLookups     *metrics.Counter
+	renderDuration   *metrics.Histogram
+	cardsServed      *metrics.Counter
+
+	mu          sync.Mutex
+	cacheHits   int
+	cacheMisses int
+	tokens      []tokenStatusSource
+}
+
+// tokenStatusSource is anything holding GitHub credentials that can tell
+// how much budget they have left: a TokenPool or a GithubAppAuth.
+type tokenStatusSource interface {
+	Status() []TokenStatus
+}
+
+func NewMetrics() *Metrics {
+	registry := metrics.NewRegistry()
+	m := &Metrics{
+		Registry: registry,
+		upstreamRequests: registry.NewCounter("readme_studio_upstream_requests_total",
+			"Requests sent to GitHub, retries included, by operation and error source.",
+			"operation", "error_source"),
+		upstreamDuration: registry.NewHistogram("readme_studio_upstream_request_duration_seconds",
+			"Time from sending a request to GitHub to having decoded its response.",
+			nil, "operation", "error_source"),
+		cacheLookups: registry.NewCounter("readme_studio_cache_lookups_total",
+			"Cache lookups of cacheable queries, by result.",
+			"result"),
+		renderDuration: registry.NewHistogram("readme_studio_render_duration_seconds",
+			"Time spent rendering a card once its data is in.",
+			nil, "card"),
+		cardsServed: registry.NewCounter("readme_studio_cards_served_total",
+			"Cards served, by card type.",
+			"card"),
+	}
+	registry.NewGaugeFunc("readme_studio_cache_hit_ratio",
+		"Share of cache lookups that were hits, 0 before the first lookup.",
+		nil, m.collectCacheHitRatio)
+	registry.NewGaugeFunc("readme_studio_rate_limit_remaining",
+		"Points left in the hourly rate limit budget of a token, by token identity. Tokens GitHub hasn't told us about yet are left out.",
+		[]string{"token"}, m.collectRateLimitRemaining)
+	return m
+}
+
+// WatchTokenPool reports the remaining budget of every token of pool.
+func (m *Metrics) WatchTokenPool(pool *TokenPool) {
+	if pool == nil {
+		return
+	}
+	m.watchTokens(pool)
+}
+
+// WatchGithubApp reports the remaining budget of the installation auth
+// authenticates as, once auth.RateLimit has seen a response.
+func (m *Metrics) WatchGithubApp(auth *GithubAppAuth) {
+	if auth == nil {
+		return
+	}
+	m.watchTokens(auth)
+}
+
+func (m *Metrics) watchTokens(source tokenStatusSource) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens = append(m.tokens, source)
+}
+
+// ObserveRender records how long rendering a card of cardType took.
+func (m *Metrics) ObserveRender(cardType string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.renderDuration.Observe(duration.Seconds(), cardType)
+}
+
+// CardServed counts a card of cardType handed out.
+func (m *Metrics) CardServed(cardType string) {
+	if m == nil {
+		return
+	}
+	m.cardsServed.Inc(cardType)
+}
+
+func (m *Metrics) observeUpstream(operation string, errData *ErrorData, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	source := metricsNoError
+	if errData != nil {
+		source = string(errData.Source)
+	}
+	m.upstreamRequests.Inc(operation, source)
+	m.upstreamDuration.Observe(duration.Seconds(), operation, source)
+}
+
+func (m *Metrics) observeCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	m.mu.Lock()
+	if hit {
+		result = "hit"
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+	m.mu.Unlock()
+	m.cacheLookups.Inc(result)
+}
+
+func (m *Metrics) collectCacheHitRatio(set func(value float64, labelValues ...string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ratio := 0.0
+	if lookups := m.cacheHits + m.cacheMisses; lookups > 0 {
+		ratio = float64(m.cacheHits) / float64(lookups)
+	}
+	set(ratio)
+}
+
+func (m *Metrics) collectRateLimitRemaining(set func(value float64, labelValues ...string)) {
+	m.mu.Lock()
+	sources := append([]tokenStatusSource(nil), m.tokens...)
+	m.mu.Unlock()
+	for _, source := range sources {
+		for _, status := range source.Status() {
+			if status.BudgetKnown {
+				set(float64(status.Budget.Remaining), status.Identity)
+			}
+		}
+	}
+}
+
+// ServeMetrics listens on addr and serves m at MetricsPath until ctx is
+// done. Requests are traced with tracer, continuing the trace of their
+// traceparent header. It returns once the listener is up, with a channel
+// that gets the error the server stopped with, nil after a clean shutdown.
+func ServeMetrics(ctx context.Context, addr string, m *Metrics, tracer *tracing.Tracer) (net.Addr, <-chan error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, m.Registry.Handler())
+	server := &http.Server{
+		Handler:           tracing.Handler(tracer, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		err := server.Serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		stopped <- err
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	return listener.Addr(), stopped, nil
+}
@@ -0,0 +1,288 @@
+// Package metrics keeps counters, gauges and histograms and writes them in
+// the Prometheus text exposition format, for any Prometheus compatible
+// scraper to collect.
+//
+// Like the tracing package, every method is safe on a nil metric, which
+// does nothing, so code can be instrumented unconditionally.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are upper bounds in seconds, from a fast cache hit to a
+// request close to its timeout.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// family is a metric and all its series, one per combination of label
+// values.
+type family struct {
+	name   string
+	help   string
+	kind   metricType
+	labels []string
+	// buckets are the upper bounds of a histogram, +Inf excluded
+	buckets []float64
+	// collect fills in a gauge from elsewhere at every scrape
+	collect func(set func(value float64, labelValues ...string))
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts holds a histogram's observations per bucket, not cumulative,
+	// with the +Inf bucket last
+	counts []uint64
+	count  uint64
+}
+
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic("metrics: " + f.name + " takes " + strconv.Itoa(len(f.labels)) + " label values")
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter only goes up.
+type Counter struct {
+	family *family
+}
+
+// Add adds value, which must not be negative, to the series of
+// labelValues.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if c == nil || value < 0 {
+		return
+	}
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+	c.family.get(labelValues).value += value
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Gauge goes up and down.
+type Gauge struct {
+	family *family
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+	g.family.get(labelValues).value = value
+}
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	family *family
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+	s := h.family.get(labelValues)
+	s.counts[sort.SearchFloat64s(h.family.buckets, value)]++
+	s.count++
+	s.value += value
+}
+
+// Registry holds metrics and writes them all out.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+func (r *Registry) register(f *family) *family {
+	f.series = map[string]*series{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registered := range r.families {
+		if registered.name == f.name {
+			panic("metrics: " + f.name + " registered twice")
+		}
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{family: r.register(&family{name: name, help: help, kind: typeCounter, labels: labels})}
+}
+
+func (r *Registry) NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{family: r.register(&family{name: name, help: help, kind: typeGauge, labels: labels})}
+}
+
+// NewGaugeFunc registers a gauge whose series collect sets anew at every
+// scrape, for values kept elsewhere.
+func (r *Registry) NewGaugeFunc(name string, help string, labels []string, collect func(set func(value float64, labelValues ...string))) {
+	r.register(&family{name: name, help: help, kind: typeGauge, labels: labels, collect: collect})
+}
+
+// NewHistogram registers a histogram with buckets as upper bounds, or
+// DefaultBuckets when nil.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{family: r.register(&family{name: name, help: help, kind: typeHistogram, labels: labels, buckets: buckets})}
+}
+
+// WriteText writes every metric in the text exposition format, families in
+// the order they were registered and series sorted by label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	for _, f := range families {
+		f.writeText(out)
+	}
+	return out.Flush()
+}
+
+// Handler serves WriteText, for mounting at /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+func (f *family) writeText(out *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.collect != nil {
+		f.series = map[string]*series{}
+		f.collect(func(value float64, labelValues ...string) {
+			f.get(labelValues).value = value
+		})
+	}
+
+	out.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	out.WriteString("# TYPE " + f.name + " " + string(f.kind) + "\n")
+
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return lessLabelValues(all[i].labelValues, all[j].labelValues)
+	})
+	for _, s := range all {
+		if f.kind != typeHistogram {
+			writeSample(out, f.name, f.labels, s.labelValues, "", "", s.value)
+			continue
+		}
+		cumulative := uint64(0)
+		for i, count := range s.counts {
+			cumulative += count
+			bound := math.Inf(1)
+			if i < len(f.buckets) {
+				bound = f.buckets[i]
+			}
+			writeSample(out, f.name+"_bucket", f.labels, s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(out, f.name+"_sum", f.labels, s.labelValues, "", "", s.value)
+		writeSample(out, f.name+"_count", f.labels, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+func writeSample(out *bufio.Writer, name string, labels []string, labelValues []string, extraLabel string, extraValue string, value float64) {
+	out.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		out.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			out.WriteString(label + `="` + escapeLabelValue(labelValues[i]) + `"`)
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				out.WriteByte(',')
+			}
+			out.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		out.WriteByte('}')
+	}
+	out.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func lessLabelValues(a []string, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,101 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestMetricsSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestMetricsSuite))
+}
+
+func (uts *UnitTestMetricsSuite) TestWriteText() {
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounter("requests_total", "Requests sent.", "operation", "error_source")
+	requests.Inc("Viewer", "none")
+	requests.Add(2, "Card", "github")
+	requests.Inc("Viewer", "none")
+	requests.Add(-1, "Viewer", "none")
+	temperature := registry.NewGauge("temperature", "Back\\slash and\nnewline.")
+	temperature.Set(21.5)
+	temperature.Set(-3)
+	duration := registry.NewHistogram("duration_seconds", "Durations.", []float64{1, 0.1}, "card")
+	duration.Observe(0.05, "repository")
+	duration.Observe(0.1, "repository")
+	duration.Observe(4, "repository")
+	registry.NewGaugeFunc("remaining", "Remaining budget.", []string{"token"}, func(set func(value float64, labelValues ...string)) {
+		set(4999, "b")
+		set(12, `a"quoted"`)
+	})
+	registry.NewCounter("unused_total", "Never counted.")
+
+	var out strings.Builder
+	uts.Require().Nil(registry.WriteText(&out))
+
+	uts.Equal(`# HELP requests_total Requests sent.
+# TYPE requests_total counter
+requests_total{operation="Card",error_source="github"} 2
+requests_total{operation="Viewer",error_source="none"} 2
+# HELP temperature Back\\slash and\nnewline.
+# TYPE temperature gauge
+temperature -3
+# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{card="repository",le="0.1"} 2
+duration_seconds_bucket{card="repository",le="1"} 2
+duration_seconds_bucket{card="repository",le="+Inf"} 3
+duration_seconds_sum{card="repository"} 4.15
+duration_seconds_count{card="repository"} 3
+# HELP remaining Remaining budget.
+# TYPE remaining gauge
+remaining{token="a\"quoted\""} 12
+remaining{token="b"} 4999
+# HELP unused_total Never counted.
+# TYPE unused_total counter
+`, out.String())
+}
+
+func (uts *UnitTestMetricsSuite) TestHandler() {
+	asserts := assert.New(uts.T())
+
+	registry := metrics.NewRegistry()
+	registry.NewCounter("cards_total", "Cards served.").Inc()
+	server := httptest.NewServer(registry.Handler())
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	uts.Require().Nil(err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	uts.Require().Nil(err)
+	asserts.Equal(metrics.ContentType, response.Header.Get("Content-Type"))
+	asserts.Contains(string(body), "\ncards_total 1\n")
+}
+
+func (uts *UnitTestMetricsSuite) TestMisuse() {
+	var counter *metrics.Counter
+	var gauge *metrics.Gauge
+	var histogram *metrics.Histogram
+	uts.NotPanics(func() {
+		counter.Inc()
+		gauge.Set(1)
+		histogram.Observe(1)
+	})
+
+	registry := metrics.NewRegistry()
+	labeled := registry.NewCounter("labeled_total", "Labeled.", "card")
+	uts.Panics(func() { labeled.Inc() })
+	uts.Panics(func() { registry.NewGauge("labeled_total", "Again.") })
+}
@@ -0,0 +1,130 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+	"github.com/abhisheksrocks/readme-studio/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestMetricsSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestMetricsSuite))
+}
+
+// scrape fetches the metrics served at addr, one line per element.
+func scrape(t *testing.T, addr string) []string {
+	response, err := http.Get("http://" + addr + main.MetricsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(string(body), "\n")
+}
+
+func (uts *UnitTestMetricsSuite) TestClientMetrics() {
+	asserts := assert.New(uts.T())
+
+	server := fakegithub.New(fakegithub.DefaultFixtures())
+	defer server.Close()
+	tokenPool := main.NewTokenPool([]string{"token"}, 0, main.DefaultTokenQuarantine)
+	clientMetrics := main.NewMetrics()
+	clientMetrics.WatchTokenPool(tokenPool)
+	client := main.NewGraphQlClient(server.Endpoint(), server.Client(), tokenPool.Middleware())
+	client.Metrics = clientMetrics
+	client.Cache = main.NewMemoryCache(10)
+	client.CacheTTL = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, stopped, err := main.ServeMetrics(ctx, "127.0.0.1:0", clientMetrics, nil)
+	uts.Require().Nil(err)
+
+	for _, name := range []string{"async_button", "async_button", "missing"} {
+		main.FetchRepositoryCard(context.Background(), client, name, fakeGithubOwner)
+	}
+	clientMetrics.ObserveRender(main.CardTypeRepository, 20*time.Millisecond)
+	clientMetrics.CardServed(main.CardTypeRepository)
+	clientMetrics.CardServed(main.CardTypeRepository)
+
+	lines := scrape(uts.T(), addr.String())
+	asserts.Contains(lines, `readme_studio_upstream_requests_total{operation="GithubRepositoryCard",error_source="github"} 1`)
+	asserts.Contains(lines, `readme_studio_upstream_requests_total{operation="GithubRepositoryCard",error_source="none"} 1`)
+	asserts.Contains(lines, `readme_studio_upstream_request_duration_seconds_count{operation="GithubRepositoryCard",error_source="none"} 1`)
+	asserts.Contains(lines, `readme_studio_cache_lookups_total{result="hit"} 1`)
+	asserts.Contains(lines, `readme_studio_cache_lookups_total{result="miss"} 2`)
+	asserts.Contains(lines, `readme_studio_cache_hit_ratio 0.3333333333333333`)
+	asserts.Contains(lines, `readme_studio_render_duration_seconds_bucket{card="repository",le="0.025"} 1`)
+	asserts.Contains(lines, `readme_studio_render_duration_seconds_bucket{card="repository",le="0.01"} 0`)
+	asserts.Contains(lines, `readme_studio_cards_served_total{card="repository"} 2`)
+
+	status := tokenPool.Status()[0]
+	uts.Require().True(status.BudgetKnown)
+	asserts.Contains(lines, `readme_studio_rate_limit_remaining{token="`+status.Identity+`"} `+strconv.Itoa(status.Budget.Remaining))
+
+	cancel()
+	select {
+	case err := <-stopped:
+		asserts.Nil(err)
+	case <-time.After(5 * time.Second):
+		uts.Fail("metrics listener didn't stop")
+	}
+}
+
+func (uts *UnitTestMetricsSuite) TestTracedScrapes() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	recorder := new(spanRecorder)
+	tracer := tracing.NewTracer(recorder)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, _, err := main.ServeMetrics(ctx, "127.0.0.1:0", main.NewMetrics(), tracer)
+	uts.Require().Nil(err)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	request, err := http.NewRequest(http.MethodGet, "http://"+addr.String()+main.MetricsPath, nil)
+	uts.Require().Nil(err)
+	request.Header.Set(tracing.TraceparentHeader, traceparent)
+
+	// Act
+	response, err := http.DefaultClient.Do(request)
+	uts.Require().Nil(err)
+	response.Body.Close()
+	uts.Require().Nil(tracer.Flush(context.Background()))
+
+	// Assert
+	spans := recorder.named("HTTP GET")
+	uts.Require().Len(spans, 1)
+	incoming, _ := tracing.ParseTraceparent(traceparent)
+	asserts.Equal(incoming.TraceID, spans[0].TraceID)
+	asserts.Equal(incoming.SpanID, spans[0].ParentSpanID)
+	asserts.Equal(tracing.SpanKindServer, spans[0].Kind)
+	asserts.Contains(spans[0].Attributes, tracing.String("http.target", main.MetricsPath))
+}
+
+func (uts *UnitTestMetricsSuite) TestNilMetrics() {
+	var clientMetrics *main.Metrics
+	uts.NotPanics(func() {
+		clientMetrics.WatchTokenPool(main.NewTokenPool([]string{"token"}, 0, 0))
+		clientMetrics.WatchGithubApp(&main.GithubAppAuth{})
+		clientMetrics.ObserveRender(main.CardTypeRepository, time.Second)
+		clientMetrics.CardServed(main.CardTypeRepository)
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Middleware is one stage of the pipeline a GraphQL request goes through.
+// It gets the next stage and returns its own: it may change the request
+// before calling next, look at the response it returns, or answer without
+// calling next at all.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc lets a plain function be a stage of the pipeline.
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// Chain puts middlewares in front of transport. The first middleware sees
+// the request first and the response last.
+func Chain(transport http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	return transport
+}
+
+// HeadersMiddleware adds headers to every request. A header with an empty
+// key or value fails the request with a RequestHeaderError before anything
+// is sent.
+func HeadersMiddleware(headers []RequestHeader) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			for _, head := range headers {
+				if empty(head.key) {
+					return nil, RequestHeaderErrorEmptyKey
+				}
+				if empty(head.value) {
+					return nil, RequestHeaderErrorEmptyValue
+				}
+			}
+			request = request.Clone(request.Context())
+			for _, head := range headers {
+				request.Header.Add(head.key, head.value)
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// AuthorizationMiddleware asks token for the token of every request, so
+// it can change from one request to the next. An empty token fails like
+// an empty header would.
+func AuthorizationMiddleware(token func(request *http.Request) (string, error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			value, err := token(request)
+			if err != nil {
+				return nil, err
+			}
+			if empty(value) {
+				return nil, RequestHeaderErrorEmptyValue
+			}
+			header := makeAuthorizationHeader(value)
+			request = request.Clone(request.Context())
+			request.Header.Set(header.key, header.value)
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// UserAgentMiddleware sets the User-Agent, which GitHub asks every client
+// to send.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			request = request.Clone(request.Context())
+			request.Header.Set("User-Agent", userAgent)
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDMiddleware tags every request that doesn't have one yet with a
+// random RequestIDHeader, to match our logs with the other side's.
+func RequestIDMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get(RequestIDHeader) == "" {
+				request = request.Clone(request.Context())
+				request.Header.Set(RequestIDHeader, newRequestID())
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+func newRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// LoggingMiddleware logs every round trip with its outcome and duration.
+// Headers are left out, they hold the token.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(request)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			requestID := request.Header.Get(RequestIDHeader)
+			if err != nil {
+				logger.Printf("%s %s failed after %s (request id %q): %v",
+					request.Method, request.URL.Redacted(), elapsed, requestID, err)
+				return response, err
+			}
+			logger.Printf("%s %s %d in %s (request id %q, github request id %q)",
+				request.Method, request.URL.Redacted(), response.StatusCode, elapsed,
+				requestID, response.Header.Get(githubRequestIDHeader))
+			return response, err
+		})
+	}
+}
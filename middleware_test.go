@@ -0,0 +1,192 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestMiddlewareSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestMiddlewareSuite))
+}
+
+// recordingMiddleware appends name to trace on the way in and out.
+func recordingMiddleware(name string, trace *[]string) main.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return main.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			*trace = append(*trace, "->"+name)
+			response, err := next.RoundTrip(request)
+			*trace = append(*trace, "<-"+name)
+			return response, err
+		})
+	}
+}
+
+func (uts *UnitTestMiddlewareSuite) TestMiddlewares() {
+
+	var tests = []struct {
+		testName    string
+		middlewares func(trace *[]string) []main.Middleware
+		assertFunc  func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData)
+	}{
+		{
+			testName: "runs stages in order",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{recordingMiddleware("a", trace), recordingMiddleware("b", trace)}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal([]string{"->a", "->b", "<-b", "<-a"}, trace)
+			},
+		},
+		{
+			testName: "sets user agent and request id",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{main.UserAgentMiddleware("readme-studio-test"), main.RequestIDMiddleware()}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal("readme-studio-test", header.Get("User-Agent"))
+				asserts.Len(header.Get(main.RequestIDHeader), 16)
+			},
+		},
+		{
+			testName: "authorizes every request",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{main.AuthorizationMiddleware(func(*http.Request) (string, error) {
+					return "ghp_test", nil
+				})}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal("bearer ghp_test", header.Get("Authorization"))
+			},
+		},
+		{
+			testName: "empty header is refused before sending",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{main.AuthorizationMiddleware(func(*http.Request) (string, error) {
+					return "", nil
+				})}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.EqualValues(0, calls)
+				asserts.Equal(main.ErrorDataSourceUs, err.Source)
+				asserts.ErrorIs(err, main.RequestHeaderErrorEmptyValue)
+			},
+		},
+		{
+			testName: "stage answers without calling next",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{func(next http.RoundTripper) http.RoundTripper {
+					return main.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Header:     http.Header{},
+							Body:       io.NopCloser(strings.NewReader(`{"data":{"viewer":{"login":"hubot"}}}`)),
+						}, nil
+					})
+				}}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.EqualValues(0, calls)
+			},
+		},
+		{
+			testName: "stage fails with its own error data",
+			middlewares: func(trace *[]string) []main.Middleware {
+				return []main.Middleware{func(next http.RoundTripper) http.RoundTripper {
+					return main.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+						return nil, &main.ErrorData{
+							Source:  main.ErrorDataSourceUs,
+							Message: string(main.GraphQlRequestErrorRateLimitBudget),
+						}
+					})
+				}}
+			},
+			assertFunc: func(t *testing.T, calls int32, header http.Header, trace []string, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.EqualValues(0, calls)
+				asserts.ErrorIs(err, main.ErrRateLimitBudget)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var calls int32
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				header = r.Header.Clone()
+				w.Write([]byte(testResponse))
+			}))
+			defer server.Close()
+
+			var trace []string
+			client := main.NewGraphQlClient(server.URL, server.Client(), v.middlewares(&trace)...)
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(context.Background(), testQuery, nil, &result)
+
+			// Assert
+			v.assertFunc(t, atomic.LoadInt32(&calls), header, trace, errorData(err))
+		})
+	}
+}
+
+func (uts *UnitTestMiddlewareSuite) TestLoggingMiddleware() {
+	asserts := assert.New(uts.T())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		w.Write([]byte(testResponse))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	logger := log.New(&output, "", 0)
+	transport := main.Chain(server.Client().Transport, main.LoggingMiddleware(logger))
+	request, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	request.Header.Set("Authorization", "bearer ghp_secret")
+	_, err := transport.RoundTrip(request)
+	asserts.Nil(err)
+	asserts.Contains(output.String(), "POST "+server.URL+" 200")
+	asserts.Contains(output.String(), "ABCD:1234")
+	asserts.NotContains(output.String(), "ghp_secret")
+
+	output.Reset()
+	failing := main.Chain(main.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), main.LoggingMiddleware(logger))
+	_, err = failing.RoundTrip(request)
+	asserts.NotNil(err)
+	asserts.Contains(output.String(), "connection refused")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+
+	"github.com/abhisheksrocks/readme-studio/tracing"
+)
+
+// GithubPageInfoModel is the pageInfo selection of a GraphQL connection.
+type GithubPageInfoModel struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// Page is one step of a Paginator. After is the cursor the page was asked
+// with and EndCursor the one to resume from, so storing EndCursor and
+// passing it back as Paginator.StartCursor continues where a walk stopped.
+type Page[T any] struct {
+	Result    GithubResultModel[T]
+	Items     int
+	After     string
+	EndCursor string
+	Err       error
+}
+
+const DefaultPageSize = 100
+
+// Paginator walks a connection page by page. Variables builds the variables
+// of one page request; a nil after asks for the first page. Connection
+// picks the pageInfo and the number of nodes out of a decoded page.
+type Paginator[T any] struct {
+	Client      *GraphQlClient
+	Query       string
+	Variables   func(first int, after *string) any
+	Connection  func(data *T) (GithubPageInfoModel, int)
+	PageSize    int
+	MaxItems    int
+	StartCursor string
+}
+
+// Pages streams pages until the connection ends, MaxItems is reached, a
+// request fails or ctx is done. A failed request is sent as a page with Err
+// set and ends the walk.
+func (p *Paginator[T]) Pages(ctx context.Context) <-chan Page[T] {
+	pages := make(chan Page[T])
+	go func() {
+		defer close(pages)
+
+		pageSize := p.PageSize
+		if pageSize <= 0 {
+			pageSize = DefaultPageSize
+		}
+		cursor := p.StartCursor
+		fetched := 0
+		for number := 1; p.MaxItems <= 0 || fetched < p.MaxItems; number++ {
+			first := pageSize
+			if p.MaxItems > 0 && p.MaxItems-fetched < first {
+				first = p.MaxItems - fetched
+			}
+			var after *string
+			if notEmpty(cursor) {
+				after = new(string)
+				*after = cursor
+			}
+
+			page := Page[T]{After: cursor}
+			pageCtx, span := p.Client.Tracer.Start(ctx, spanPage, tracing.SpanKindInternal)
+			span.SetAttributes(
+				tracing.String(attributeOperation, operationName(p.Query)),
+				tracing.Int(attributePage, number),
+			)
+			page.Err = p.Client.Do(pageCtx, p.Query, p.Variables(first, after), &page.Result)
+			var pageInfo GithubPageInfoModel
+			if page.Err == nil {
+				pageInfo, page.Items = p.Connection(&page.Result.Data)
+				page.EndCursor = pageInfo.EndCursor
+			}
+			span.SetAttributes(tracing.Int(attributePageItems, page.Items))
+			endSpan(span, page.Err)
+
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+			if page.Err != nil || !pageInfo.HasNextPage || page.Items == 0 {
+				return
+			}
+			fetched += page.Items
+			cursor = pageInfo.EndCursor
+		}
+	}()
+	return pages
+}
@@ -0,0 +1,143 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestPaginatorSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestPaginatorSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestPaginatorSuite))
+}
+
+var testRepositoryNames = []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+
+// repositoriesHandler serves testRepositoryNames as a connection whose
+// cursors are plain indexes.
+func repositoriesHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Variables main.GithubUserRepositoriesVariables `json:"variables"`
+	}
+	raw, _ := io.ReadAll(r.Body)
+	json.Unmarshal(raw, &body)
+
+	start := 0
+	if body.Variables.After != nil {
+		start, _ = strconv.Atoi(*body.Variables.After)
+	}
+	end := start + body.Variables.First
+	if end > len(testRepositoryNames) {
+		end = len(testRepositoryNames)
+	}
+
+	nodes := []map[string]string{}
+	for _, name := range testRepositoryNames[start:end] {
+		nodes = append(nodes, map[string]string{"name": name})
+	}
+	var result main.GithubResultModel[map[string]any]
+	result.Data = map[string]any{
+		"user": map[string]any{
+			"repositories": map[string]any{
+				"totalCount": len(testRepositoryNames),
+				"pageInfo": map[string]any{
+					"hasNextPage": end < len(testRepositoryNames),
+					"endCursor":   fmt.Sprint(end),
+				},
+				"nodes": nodes,
+			},
+		},
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+func (uts *UnitTestPaginatorSuite) TestPages() {
+
+	var tests = []struct {
+		testName    string
+		pageSize    int
+		maxItems    int
+		startCursor string
+		assertFunc  func(t *testing.T, pages []main.Page[main.GithubUserRepositoriesModel])
+	}{
+		{
+			testName: "walks whole connection",
+			pageSize: 2,
+			assertFunc: func(t *testing.T, pages []main.Page[main.GithubUserRepositoriesModel]) {
+				asserts := assert.New(t)
+				asserts.Len(pages, 3)
+				asserts.Equal([]int{2, 2, 1}, pageItems(pages))
+				asserts.Equal("", pages[0].After)
+				asserts.Equal("2", pages[1].After)
+				asserts.Equal("5", pages[2].EndCursor)
+			},
+		},
+		{
+			testName: "stops at max items",
+			pageSize: 2,
+			maxItems: 3,
+			assertFunc: func(t *testing.T, pages []main.Page[main.GithubUserRepositoriesModel]) {
+				asserts := assert.New(t)
+				asserts.Equal([]int{2, 1}, pageItems(pages))
+				asserts.Equal("gamma", pages[1].Result.Data.User.Repositories.Nodes[0].Name)
+			},
+		},
+		{
+			testName:    "resumes from cursor",
+			pageSize:    10,
+			startCursor: "3",
+			assertFunc: func(t *testing.T, pages []main.Page[main.GithubUserRepositoriesModel]) {
+				asserts := assert.New(t)
+				asserts.Equal([]int{2}, pageItems(pages))
+				asserts.Equal("delta", pages[0].Result.Data.User.Repositories.Nodes[0].Name)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(repositoriesHandler))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			paginator := main.NewUserRepositoriesPaginator(client, testLogin, v.maxItems)
+			paginator.PageSize = v.pageSize
+			paginator.StartCursor = v.startCursor
+
+			// Act
+			var pages []main.Page[main.GithubUserRepositoriesModel]
+			for page := range paginator.Pages(context.Background()) {
+				assert.Nil(t, page.Err)
+				pages = append(pages, page)
+			}
+
+			// Assert
+			v.assertFunc(t, pages)
+		})
+	}
+}
+
+func pageItems(pages []main.Page[main.GithubUserRepositoriesModel]) []int {
+	items := []int{}
+	for _, page := range pages {
+		items = append(items, page.Items)
+	}
+	return items
+}
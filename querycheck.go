@@ -0,0 +1,149 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+// githubSchemaSource is the snapshot of GitHub's schema model queries are
+// validated against.
+//
+//go:embed schema/github.graphql
+var githubSchemaSource string
+
+var githubSchema struct {
+	once   sync.Once
+	schema *graphql.Schema
+	err    error
+}
+
+// GithubSchema returns the bundled snapshot of GitHub's GraphQL schema.
+func GithubSchema() (*graphql.Schema, error) {
+	githubSchema.once.Do(func() {
+		githubSchema.schema, githubSchema.err = graphql.ParseSchema(githubSchemaSource)
+	})
+	return githubSchema.schema, githubSchema.err
+}
+
+// ModelQuery is a document one of the models sends, with variables as big
+// as the model ever asks for, so its cost is estimated for the worst case.
+type ModelQuery struct {
+	Operation string
+	Query     string
+	Variables any
+}
+
+// ModelQueries lists every document the models send.
+func ModelQueries() []ModelQuery {
+	var card GithubRepositoryCardModel
+	cardQuery, cardVariables := card.makeQuery("readme-studio", "abhisheksrocks")
+
+	batchRequests := make([]GithubRepositoryCardRequest, MaxRepositoryCardBatch)
+	for i := range batchRequests {
+		batchRequests[i] = GithubRepositoryCardRequest{Name: "readme-studio", Owner: "abhisheksrocks"}
+	}
+	batchQuery, batchVariables := makeRepositoryCardBatchQuery(batchRequests)
+
+	var userRepositories GithubUserRepositoriesModel
+	userRepositoriesQuery, userRepositoriesVariables := userRepositories.makeQuery("abhisheksrocks")
+
+	return []ModelQuery{
+		{Operation: "GithubRepositoryCard", Query: cardQuery, Variables: cardVariables},
+		{Operation: "GithubRepositoryCardBatch", Query: batchQuery, Variables: batchVariables},
+		{Operation: "GithubUserRepositories", Query: userRepositoriesQuery, Variables: userRepositoriesVariables(DefaultPageSize, nil)},
+	}
+}
+
+// QueryReport is the outcome of validating one query. Cost is only set
+// when the query is valid.
+type QueryReport struct {
+	Operation string
+	Errors    []error
+	Cost      graphql.Cost
+}
+
+// ValidateQuery checks query, completed with the GithubFragments it
+// spreads, against schema, both as written and with the rateLimit
+// selection the client may inject into it, and estimates what sending it
+// with variables costs. Queries that could return more nodes than GitHub
+// allows are reported as invalid.
+func ValidateQuery(schema *graphql.Schema, query string, variables any) QueryReport {
+	report := QueryReport{Operation: operationName(query)}
+	query, err := GithubFragments.Complete(query)
+	if err != nil {
+		report.Errors = []error{err}
+		return report
+	}
+	document, err := graphql.Parse(query)
+	if err != nil {
+		report.Errors = []error{err}
+		return report
+	}
+	if report.Errors = graphql.Validate(schema, document); len(report.Errors) > 0 {
+		return report
+	}
+
+	injected, err := graphql.Parse(injectIntoOperation(query, rateLimitSelection))
+	if err == nil {
+		report.Errors = graphql.Validate(schema, injected)
+	} else {
+		report.Errors = []error{err}
+	}
+	for i, err := range report.Errors {
+		report.Errors[i] = fmt.Errorf("with rateLimit injected: %w", err)
+	}
+	if len(report.Errors) > 0 {
+		return report
+	}
+
+	if report.Cost, err = estimateQueryCost(query, variables); err != nil {
+		report.Errors = []error{err}
+	} else if report.Cost.Nodes > graphql.MaxNodes {
+		report.Errors = []error{fmt.Errorf("query may return %d nodes, GitHub allows at most %d", report.Cost.Nodes, graphql.MaxNodes)}
+	}
+	return report
+}
+
+// ValidateModelQueries validates every query in ModelQueries against the
+// bundled schema.
+func ValidateModelQueries() ([]QueryReport, error) {
+	schema, err := GithubSchema()
+	if err != nil {
+		return nil, err
+	}
+	modelQueries := ModelQueries()
+	reports := make([]QueryReport, len(modelQueries))
+	for i, modelQuery := range modelQueries {
+		reports[i] = ValidateQuery(schema, modelQuery.Query, modelQuery.Variables)
+		reports[i].Operation = modelQuery.Operation
+	}
+	return reports, nil
+}
+
+// validateQueries is the validate-queries command. It prints a report for
+// every model query to w and returns the exit code, which is 1 as soon as
+// a query is invalid.
+func validateQueries(w io.Writer) int {
+	reports, err := ValidateModelQueries()
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	exitCode := 0
+	for _, report := range reports {
+		if len(report.Errors) == 0 {
+			fmt.Fprintf(w, "ok\t%s\t%d point(s), up to %d node(s)\n", report.Operation, report.Cost.Points, report.Cost.Nodes)
+			continue
+		}
+		exitCode = 1
+		fmt.Fprintf(w, "FAIL\t%s\n", report.Operation)
+		for _, err := range report.Errors {
+			fmt.Fprintf(w, "\t%s\n", err)
+		}
+	}
+	return exitCode
+}
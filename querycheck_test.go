@@ -0,0 +1,122 @@
+package main_test
+
+import (
+	"testing"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/graphql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestQueryCheckSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestQueryCheckSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestQueryCheckSuite))
+}
+
+func (uts *UnitTestQueryCheckSuite) TestModelQueriesMatchSchema() {
+	asserts := assert.New(uts.T())
+
+	// Act
+	reports, err := main.ValidateModelQueries()
+
+	// Assert
+	uts.Require().Nil(err)
+	asserts.Len(reports, len(main.ModelQueries()))
+	for _, report := range reports {
+		asserts.Empty(report.Errors, report.Operation)
+		asserts.Equal(1, report.Cost.Points, report.Operation)
+	}
+}
+
+func (uts *UnitTestQueryCheckSuite) TestValidateQuery() {
+
+	var tests = []struct {
+		testName    string
+		query       string
+		variables   any
+		arrangeFunc func(t *testing.T) *graphql.Schema
+		assertFunc  func(t *testing.T, report main.QueryReport)
+	}{
+		{
+			testName:  "typo in a field",
+			query:     `query Typo($owner: String!) { repository(owner: $owner, name: "a") { stargazersCount } }`,
+			variables: map[string]string{"owner": "octocat"},
+			assertFunc: func(t *testing.T, report main.QueryReport) {
+				asserts := assert.New(t)
+				asserts.Equal("Typo", report.Operation)
+				asserts.Len(report.Errors, 1, "%v", report.Errors)
+			},
+		},
+		{
+			testName: "syntax error",
+			query:    `query Broken { repository(owner: "a", name: "b") { name }`,
+			assertFunc: func(t *testing.T, report main.QueryReport) {
+				assert.Len(t, report.Errors, 1, "%v", report.Errors)
+			},
+		},
+		{
+			testName: "too many nodes",
+			query: `query Huge {
+				user(login: "a") {
+					repositories(first: 100) { nodes { stargazers(first: 100) { nodes {
+						repositories(first: 100) { totalCount }
+					} } } }
+				}
+			}`,
+			assertFunc: func(t *testing.T, report main.QueryReport) {
+				assert.Len(t, report.Errors, 1, "%v", report.Errors)
+			},
+		},
+		{
+			testName: "valid query",
+			query:    `query Valid { viewer { login } }`,
+			assertFunc: func(t *testing.T, report main.QueryReport) {
+				asserts := assert.New(t)
+				asserts.Empty(report.Errors)
+				asserts.Equal(1, report.Cost.Points)
+			},
+		},
+		{
+			testName: "checked against the given schema",
+			query:    `query Valid { viewer { login } }`,
+			arrangeFunc: func(t *testing.T) *graphql.Schema {
+				schema, err := graphql.ParseSchema(`type Query { viewer: User! } type User { name: String! }`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return schema
+			},
+			assertFunc: func(t *testing.T, report main.QueryReport) {
+				assert.Len(t, report.Errors, 1, "%v", report.Errors)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var schema *graphql.Schema
+			if v.arrangeFunc != nil {
+				schema = v.arrangeFunc(t)
+			} else {
+				githubSchema, err := main.GithubSchema()
+				uts.Require().Nil(err)
+				schema = githubSchema
+			}
+
+			// Act
+			report := main.ValidateQuery(schema, v.query, v.variables)
+
+			// Assert
+			v.assertFunc(t, report)
+		})
+	}
+}
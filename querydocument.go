@@ -0,0 +1,127 @@
+package main
+
+import "strings"
+
+// walkDocument calls visit for every byte of a GraphQL document that is
+// neither inside a string nor a comment, together with the selection set
+// depth at that byte. Walking stops once visit returns false.
+func walkDocument(document string, visit func(i int, depth int) bool) {
+	depth := 0
+	for i := 0; i < len(document); i++ {
+		switch ch := document[i]; {
+		case ch == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(document[i:], `"""`):
+			end := strings.Index(document[i+3:], `"""`)
+			if end < 0 {
+				return
+			}
+			i += end + 5
+			continue
+		case ch == '"':
+			for i++; i < len(document) && document[i] != '"'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+			continue
+		case ch == '}':
+			depth--
+		}
+		if !visit(i, depth) {
+			return
+		}
+		if document[i] == '{' {
+			depth++
+		}
+	}
+}
+
+// isIdempotentQuery reports whether a document is safe to send twice, which
+// is the case as long as it holds no mutation.
+func isIdempotentQuery(query string) bool {
+	idempotent := true
+	walkDocument(query, func(i int, depth int) bool {
+		if depth == 0 && isKeywordAt(query, i, "mutation") {
+			idempotent = false
+		}
+		return idempotent
+	})
+	return idempotent
+}
+
+// injectIntoOperation adds selection to the top level selection set of the
+// first query operation in document. Documents without one are returned
+// unchanged.
+func injectIntoOperation(document string, selection string) string {
+	inFragment, inMutation := false, false
+	closing := -1
+	walkDocument(document, func(i int, depth int) bool {
+		if depth > 0 {
+			return true
+		}
+		switch {
+		case document[i] == '}':
+			if !inFragment && !inMutation {
+				closing = i
+				return false
+			}
+			inFragment, inMutation = false, false
+		case isKeywordAt(document, i, "fragment"):
+			inFragment = true
+		case isKeywordAt(document, i, "mutation"), isKeywordAt(document, i, "subscription"):
+			inMutation = true
+		}
+		return true
+	})
+	if closing < 0 {
+		return document
+	}
+	return document[:closing] + "\t" + selection + "\n" + document[closing:]
+}
+
+func isKeywordAt(document string, i int, keyword string) bool {
+	end := i + len(keyword)
+	return strings.HasPrefix(document[i:], keyword) &&
+		(i == 0 || !isNameByte(document[i-1]) && document[i-1] != '$') &&
+		(end == len(document) || !isNameByte(document[end]))
+}
+
+func isNameByte(ch byte) bool {
+	return ch == '_' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// operationName returns the name of the first operation in document, or an
+// empty string when it is anonymous.
+func operationName(document string) string {
+	name := ""
+	inFragment := false
+	walkDocument(document, func(i int, depth int) bool {
+		if depth > 0 {
+			return true
+		}
+		switch {
+		case document[i] == '}':
+			inFragment = false
+		case isKeywordAt(document, i, "fragment"):
+			inFragment = true
+		case inFragment:
+		case isKeywordAt(document, i, "query"), isKeywordAt(document, i, "mutation"), isKeywordAt(document, i, "subscription"):
+			rest := strings.TrimLeft(document[i:], "abcdefghijklmnopqrstuvwxyz")
+			rest = strings.TrimLeft(rest, " \t\r\n,")
+			end := 0
+			for end < len(rest) && isNameByte(rest[end]) {
+				end++
+			}
+			name = rest[:end]
+			return false
+		case document[i] == '{':
+			return false
+		}
+		return true
+	})
+	return name
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abhisheksrocks/readme-studio/graphql"
+)
+
+// RateLimitBudget is what GitHub last told us about the hourly points
+// budget of the token in use. Cost is the price of the latest query and is
+// only known when the rateLimit field was injected into it.
+type RateLimitBudget struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+	Cost      int
+	UpdatedAt time.Time
+}
+
+type RateLimitAction string
+
+const (
+	RateLimitActionRefuse RateLimitAction = "refuse"
+	RateLimitActionWait   RateLimitAction = "wait"
+)
+
+// RateLimitTracker keeps a live RateLimitBudget from response headers and
+// guards calls once Remaining drops to Reserve, either refusing them or
+// holding them until the budget resets. Calls on their way count against
+// Remaining with their estimated cost until their response is in, so
+// concurrent calls can't all slip under Reserve at once.
+type RateLimitTracker struct {
+	Reserve int
+	Action  RateLimitAction
+
+	mu     sync.Mutex
+	budget RateLimitBudget
+	known  bool
+	// reserved is the estimated cost of the calls sent but not settled yet
+	reserved int
+}
+
+func NewRateLimitTracker(reserve int, action RateLimitAction) *RateLimitTracker {
+	return &RateLimitTracker{
+		Reserve: reserve,
+		Action:  action,
+	}
+}
+
+// Snapshot returns the current budget and whether any response has been
+// seen yet.
+func (t *RateLimitTracker) Snapshot() (RateLimitBudget, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.budget, t.known
+}
+
+func (t *RateLimitTracker) observeHeader(header http.Header, now time.Time) {
+	limit, limitErr := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if limitErr != nil || remainingErr != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budget.Limit = limit
+	t.budget.Remaining = remaining
+	if used, err := strconv.Atoi(header.Get("X-RateLimit-Used")); err == nil {
+		t.budget.Used = used
+	} else {
+		t.budget.Used = limit - remaining
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.budget.Reset = time.Unix(reset, 0)
+	}
+	t.budget.UpdatedAt = now
+	t.known = true
+}
+
+func (t *RateLimitTracker) observeField(field GithubRateLimitModel, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budget.Cost = field.Cost
+	t.budget.Remaining = field.Remaining
+	if field.Limit > 0 {
+		t.budget.Limit = field.Limit
+		t.budget.Used = field.Limit - field.Remaining
+	}
+	if !field.ResetAt.IsZero() {
+		t.budget.Reset = field.ResetAt
+	}
+	t.budget.UpdatedAt = now
+	t.known = true
+}
+
+// reserve is called before every request with its estimated cost, which
+// it holds until settle gives it back. It returns an ErrorData when the
+// call must not go out.
+func (t *RateLimitTracker) reserve(ctx context.Context, cost int) *ErrorData {
+	for {
+		t.mu.Lock()
+		wait := time.Until(t.budget.Reset)
+		if !t.known || wait <= 0 || t.budget.Remaining-t.reserved-cost >= t.Reserve {
+			t.reserved += cost
+			t.mu.Unlock()
+			return nil
+		}
+		t.mu.Unlock()
+
+		if t.Action != RateLimitActionWait || !fitsDeadline(ctx, wait) {
+			return &ErrorData{
+				Source:  ErrorDataSourceUs,
+				Message: string(GraphQlRequestErrorRateLimitBudget),
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return contextErrorData(ctx, ctx)
+		}
+	}
+}
+
+// settle gives back what reserve held for a call, once its response has
+// been observed or it failed without one.
+func (t *RateLimitTracker) settle(cost int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reserved -= cost
+	if t.reserved < 0 {
+		t.reserved = 0
+	}
+}
+
+// estimateQueryCost estimates what sending the completed query with
+// variables costs, the way ValidateQuery does.
+func estimateQueryCost(query string, variables any) (graphql.Cost, error) {
+	document, err := graphql.Parse(query)
+	if err != nil {
+		return graphql.Cost{}, err
+	}
+	var variableValues map[string]any
+	if raw, err := json.Marshal(variables); err == nil {
+		json.Unmarshal(raw, &variableValues)
+	}
+	return graphql.EstimateCost(document, operationName(query), variableValues)
+}
+
+// rateLimitAlias keeps the injected field apart from anything a model may
+// select on its own.
+const rateLimitAlias = "readmeStudioRateLimit"
+
+const rateLimitSelection = rateLimitAlias + ": rateLimit { cost limit remaining resetAt }"
+
+type GithubRateLimitModel struct {
+	Cost      int       `json:"cost"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// rateLimitClaim goes down with a request to the stage that authorizes it,
+// which holds cost on the budget of the token it sends the request with
+// and names that budget in tracker, for the rateLimit field of the
+// response to be observed into.
+type rateLimitClaim struct {
+	cost    int
+	tracker *RateLimitTracker
+}
+
+type rateLimitClaimKey struct{}
+
+func withRateLimitClaim(ctx context.Context, claim *rateLimitClaim) context.Context {
+	return context.WithValue(ctx, rateLimitClaimKey{}, claim)
+}
+
+// rateLimitClaimFrom returns the claim of the request ctx belongs to, nil
+// for requests sent by something other than a GraphQlClient.
+func rateLimitClaimFrom(ctx context.Context) *rateLimitClaim {
+	claim, _ := ctx.Value(rateLimitClaimKey{}).(*rateLimitClaim)
+	return claim
+}
+
+func (c *rateLimitClaim) estimatedCost() int {
+	if c == nil || c.cost < 1 {
+		return 1
+	}
+	return c.cost
+}
+
+func (c *rateLimitClaim) observedBy(tracker *RateLimitTracker) {
+	if c != nil {
+		c.tracker = tracker
+	}
+}
@@ -0,0 +1,203 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestRateLimitSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestRateLimitSuite))
+}
+
+const testFragmentQuery = `fragment ViewerFields on User { login }
+query Viewer { viewer { ...ViewerFields } }`
+
+func (uts *UnitTestRateLimitSuite) TestTracker() {
+
+	var tests = []struct {
+		testName   string
+		query      string
+		reserve    int
+		action     main.RateLimitAction
+		inject     bool
+		handler    func(w http.ResponseWriter, query string, call int32)
+		assertFunc func(t *testing.T, tracker *main.RateLimitTracker, calls int32, errs []*main.ErrorData)
+	}{
+		{
+			testName: "reads headers",
+			query:    testQuery,
+			handler: func(w http.ResponseWriter, query string, call int32) {
+				w.Header().Set("X-RateLimit-Limit", "5000")
+				w.Header().Set("X-RateLimit-Remaining", "4990")
+				w.Header().Set("X-RateLimit-Used", "10")
+				w.Header().Set("X-RateLimit-Reset", "1700000000")
+				w.Write([]byte(testResponse))
+			},
+			assertFunc: func(t *testing.T, tracker *main.RateLimitTracker, calls int32, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				budget, ok := tracker.Snapshot()
+				asserts.True(ok)
+				asserts.Equal(5000, budget.Limit)
+				asserts.Equal(4990, budget.Remaining)
+				asserts.Equal(10, budget.Used)
+				asserts.Equal(int64(1700000000), budget.Reset.Unix())
+			},
+		},
+		{
+			testName: "injects rateLimit field after fragments",
+			query:    testFragmentQuery,
+			inject:   true,
+			handler: func(w http.ResponseWriter, query string, call int32) {
+				if strings.Index(query, "rateLimit { cost") < strings.Index(query, "query Viewer") {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(`{"data":{"viewer":{"login":"octocat"},"readmeStudioRateLimit":{"cost":1,"limit":5000,"remaining":4321,"resetAt":"2030-01-01T00:00:00Z"}}}`))
+			},
+			assertFunc: func(t *testing.T, tracker *main.RateLimitTracker, calls int32, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				budget, ok := tracker.Snapshot()
+				asserts.True(ok)
+				asserts.Equal(1, budget.Cost)
+				asserts.Equal(4321, budget.Remaining)
+			},
+		},
+		{
+			testName: "refuses once reserve is reached",
+			query:    testQuery,
+			reserve:  10,
+			action:   main.RateLimitActionRefuse,
+			handler: func(w http.ResponseWriter, query string, call int32) {
+				w.Header().Set("X-RateLimit-Limit", "5000")
+				w.Header().Set("X-RateLimit-Remaining", "10")
+				w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+				w.Write([]byte(testResponse))
+			},
+			assertFunc: func(t *testing.T, tracker *main.RateLimitTracker, calls int32, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				asserts.NotNil(errs[1])
+				asserts.Equal(string(main.GraphQlRequestErrorRateLimitBudget), errs[1].Message)
+				asserts.EqualValues(1, calls)
+			},
+		},
+		{
+			testName: "waits for reset",
+			query:    testQuery,
+			action:   main.RateLimitActionWait,
+			inject:   true,
+			handler: func(w http.ResponseWriter, query string, call int32) {
+				resetAt, _ := json.Marshal(time.Now().Add(30 * time.Millisecond))
+				w.Write([]byte(`{"data":{"readmeStudioRateLimit":{"cost":1,"limit":5000,"remaining":0,"resetAt":` + string(resetAt) + `}}}`))
+			},
+			assertFunc: func(t *testing.T, tracker *main.RateLimitTracker, calls int32, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(errs[0])
+				asserts.Nil(errs[1])
+				asserts.EqualValues(2, calls)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body main.GraphQlQuery
+				raw, _ := io.ReadAll(r.Body)
+				json.Unmarshal(raw, &body)
+				v.handler(w, body.Query, atomic.AddInt32(&calls, 1))
+			}))
+			defer server.Close()
+
+			tracker := main.NewRateLimitTracker(v.reserve, v.action)
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.RateLimit = tracker
+			client.InjectRateLimit = v.inject
+
+			// Act
+			errs := make([]*main.ErrorData, 2)
+			for i := range errs {
+				var result graphQlTestResult
+				errs[i] = errorData(client.Do(context.Background(), v.query, nil, &result))
+			}
+
+			// Assert
+			v.assertFunc(t, tracker, atomic.LoadInt32(&calls), errs)
+		})
+	}
+}
+
+func (uts *UnitTestRateLimitSuite) TestConcurrentCallsHoldTheirCost() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-release
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "12")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.Write([]byte(testResponse))
+	}))
+	defer server.Close()
+
+	client := main.NewGraphQlClient(server.URL, server.Client())
+	client.RateLimit = main.NewRateLimitTracker(10, main.RateLimitActionRefuse)
+	var result graphQlTestResult
+	uts.Require().Nil(client.Do(context.Background(), testQuery, nil, &result))
+
+	// Act
+	errs := make(chan *main.ErrorData)
+	for i := 0; i < 5; i++ {
+		go func() {
+			var result graphQlTestResult
+			errs <- errorData(client.Do(context.Background(), testQuery, nil, &result))
+		}()
+	}
+	var refused []*main.ErrorData
+	for len(refused) < 3 {
+		select {
+		case err := <-errs:
+			refused = append(refused, err)
+		case <-time.After(5 * time.Second):
+			close(release)
+			uts.FailNow("calls past the reserve weren't refused")
+		}
+	}
+	close(release)
+	sent := []*main.ErrorData{<-errs, <-errs}
+
+	// Assert
+	for _, err := range refused {
+		asserts.ErrorIs(err, main.ErrRateLimitBudget)
+	}
+	asserts.Equal([]*main.ErrorData{nil, nil}, sent)
+	asserts.EqualValues(3, atomic.LoadInt32(&calls))
+}
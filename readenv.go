@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -57,11 +59,11 @@ func (e envKeyValue) GetCacheValue() string {
 
 func (e *envKeyValue) GetValue() (string, error) {
 	if empty(e.KeyData.Key) {
-		return "", errors.New(string(ReadEnvErrorInvalidKey))
+		return "", ReadEnvErrorInvalidKey
 	}
 	v := e.environment.Getenv(e.KeyData.Key)
 	if empty(v) {
-		return "", errors.New(string(ReadEnvErrorValueNotFound))
+		return "", ReadEnvErrorValueNotFound
 	}
 	e.val = v
 	return v, nil
@@ -77,14 +79,14 @@ func NewReadEnv(envPath string, exampleEnvPath string, key EnvKey, environment R
 
 	if notEmpty(exampleEnvPath) {
 		if !environment.FileExist(exampleEnvPath) {
-			return nil, errors.New(string(ReadEnvErrorExampleFileNotFound))
+			return nil, ReadEnvErrorExampleFileNotFound
 		}
 	}
 
 	if notEmpty(envPath) {
 		err := environment.Load(envPath)
 		if err != nil {
-			return nil, errors.New(string(ReadEnvErrorFileNotFound))
+			return nil, ReadEnvErrorFileNotFound
 		}
 	}
 
@@ -105,3 +107,46 @@ func NewReadEnv(envPath string, exampleEnvPath string, key EnvKey, environment R
 		KeyVal:          &keyValueData,
 	}, err
 }
+
+// Lookup reads an optional key from the same environment the required key
+// was read from.
+func (r *ReadEnv) Lookup(key EnvKey) (string, error) {
+	keyValueData := envKeyValue{
+		KeyData:     key,
+		val:         "",
+		environment: r.KeyVal.environment,
+	}
+	return keyValueData.GetValue()
+}
+
+// LookupAll reads a key that may hold several values, either as a comma
+// separated list or spread over numbered keys: KEY, KEY_2, KEY_3 and so on
+// up to the first one missing. Repeated values are only returned once.
+func (r *ReadEnv) LookupAll(key EnvKey) ([]string, error) {
+	var values []string
+	seen := map[string]bool{}
+	for i := 1; ; i++ {
+		numberedKey := key
+		if i > 1 {
+			numberedKey.Key = key.Key + "_" + strconv.Itoa(i)
+		}
+		value, err := r.Lookup(numberedKey)
+		if errors.Is(err, ReadEnvErrorValueNotFound) && i > 1 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if notEmpty(item) && !seen[item] {
+				seen[item] = true
+				values = append(values, item)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, ReadEnvErrorValueNotFound
+	}
+	return values, nil
+}
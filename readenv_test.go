@@ -10,6 +10,7 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/abhisheksrocks/readme-studio/mocks"
@@ -735,3 +736,61 @@ func (its *IntTestReadEnvSuite) Test_OnlyEmptyKey() {
 
 	refData.assertions(its, refData, reVal, err)
 }
+
+func (uts *UnitTestReadEnvSuite) TestLookupAll() {
+
+	const key = "GITHUB_TOKEN"
+
+	var tests = []struct {
+		testName    string
+		environment map[string]string
+		assertFunc  func(t *testing.T, values []string, err error)
+	}{
+		{
+			testName:    "single value",
+			environment: map[string]string{key: "a"},
+			assertFunc: func(t *testing.T, values []string, err error) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal([]string{"a"}, values)
+			},
+		},
+		{
+			testName:    "comma list and numbered keys",
+			environment: map[string]string{key: "a, b,,", key + "_2": "c", key + "_3": "a,d", key + "_5": "skipped"},
+			assertFunc: func(t *testing.T, values []string, err error) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal([]string{"a", "b", "c", "d"}, values)
+			},
+		},
+		{
+			testName:    "only separators",
+			environment: map[string]string{key: " , "},
+			assertFunc: func(t *testing.T, values []string, err error) {
+				assert.ErrorIs(t, err, main.ReadEnvErrorValueNotFound)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			m := new(mocks.ReadEnvEnvironment)
+			m.On("Getenv", mock.AnythingOfType("string")).Return(func(name string) string {
+				return v.environment[name]
+			})
+			readEnv, err := main.NewReadEnv("", "", main.EnvKey{Key: key}, m)
+			uts.Require().Nil(err)
+
+			// Act
+			values, err := readEnv.LookupAll(main.EnvKey{Key: key})
+
+			// Assert
+			v.assertFunc(t, values, err)
+		})
+	}
+}
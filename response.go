@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// DefaultMaxResponseBytes bounds the responses of a client that doesn't set
+// GraphQlClient.MaxResponseBytes. A full page of 100 repositories is well
+// under a megabyte.
+const DefaultMaxResponseBytes = 16 << 20
+
+// errResponseTooLarge is what boundedReader fails with once the limit is
+// crossed, for the decoder to pass back to us.
+var errResponseTooLarge = errors.New("response body exceeds limit")
+
+// bodyReadError is what boundedReader fails with when reading the body
+// itself failed, like on a connection reset, so the failure isn't taken
+// for a body we couldn't decode.
+type bodyReadError struct {
+	err error
+}
+
+func (e bodyReadError) Error() string {
+	return e.err.Error()
+}
+
+func (e bodyReadError) Unwrap() error {
+	return e.err
+}
+
+// boundedReader reads at most limit bytes and fails, instead of quietly
+// stopping the way io.LimitReader does, when there is more.
+type boundedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func newBoundedReader(reader io.Reader, limit int64) *boundedReader {
+	return &boundedReader{
+		reader:    reader,
+		remaining: limit,
+	}
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errResponseTooLarge
+	}
+	// One byte past the limit tells a body of exactly limit bytes from a
+	// longer one.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.reader.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), errResponseTooLarge
+	}
+	if err != nil && err != io.EOF {
+		err = bodyReadError{err: err}
+	}
+	return n, err
+}
+
+// responseSink is decoded into from the response body. The decoder reads
+// the whole body before handing it over, and the sink then decodes it
+// through a responseEnvelope: the data into the result, the errors next to
+// it. keepBody copies the body on top of that, for the cache.
+type responseSink struct {
+	client *GraphQlClient
+	result interface{}
+	info   *attemptInfo
+	claim  *rateLimitClaim
+	// keepBody copies the raw response into info.body, for the cache.
+	keepBody bool
+	errData  *ErrorData
+}
+
+func (s *responseSink) UnmarshalJSON(raw []byte) error {
+	var githubErrors []GithubErrorModel
+	envelope := responseEnvelope{
+		Data:   responseData{scan: s.client.InjectRateLimit || s.client.AllowPartialData},
+		Errors: &githubErrors,
+	}
+	if result, ok := s.result.(githubResult); ok {
+		envelope.Data.target, envelope.Errors = result.resultTargets()
+	} else if err := json.Unmarshal(raw, s.result); err != nil {
+		return err
+	}
+	// "errors": null sets envelope.Errors to nil rather than through it.
+	errorsTarget := envelope.Errors
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return err
+	}
+	if s.keepBody {
+		s.info.body = append([]byte(nil), raw...)
+	}
+	if rateLimit := envelope.Data.rateLimit; rateLimit != nil && s.client.InjectRateLimit {
+		s.info.cost = rateLimit.Cost
+		if s.client.RateLimit != nil {
+			s.client.RateLimit.observeField(*rateLimit, time.Now())
+		}
+		if s.claim.tracker != nil {
+			s.claim.tracker.observeField(*rateLimit, time.Now())
+		}
+	}
+	s.errData = s.client.graphQlErrorData(*errorsTarget, envelope.Data.hasData, s.info)
+	return nil
+}
+
+// githubResult is implemented by *GithubResultModel, whose data and errors
+// a response is decoded into directly. Any other result is decoded from
+// the whole body on its own.
+type githubResult interface {
+	resultTargets() (data any, githubErrors *[]GithubErrorModel)
+}
+
+func (r *GithubResultModel[data]) resultTargets() (any, *[]GithubErrorModel) {
+	return &r.Data, &r.Errors
+}
+
+// responseEnvelope is what the body of a 2xx response is decoded into,
+// with Errors pointing at where the caller wants them.
+type responseEnvelope struct {
+	Data   responseData        `json:"data"`
+	Errors *[]GithubErrorModel `json:"errors"`
+}
+
+// responseData decodes the data of a response into target. With scan set
+// it goes over the top level fields of the data a second time, decoding
+// the injected rateLimit field and noting whether any other field came
+// back non-null, for partial data.
+type responseData struct {
+	target    any
+	scan      bool
+	rateLimit *GithubRateLimitModel
+	hasData   bool
+}
+
+func (d *responseData) UnmarshalJSON(raw []byte) error {
+	if d.target != nil {
+		if err := json.Unmarshal(raw, d.target); err != nil {
+			return err
+		}
+	}
+	if d.scan {
+		d.rateLimit, d.hasData = scanData(raw)
+	}
+	return nil
+}
+
+// scanData reads the injected rateLimit field of data and whether any
+// other field of it is non-null, in one pass over its top level fields.
+// Anything it can't read is left out, the data itself already decoded.
+func scanData(data []byte) (*GithubRateLimitModel, bool) {
+	var rateLimit *GithubRateLimitModel
+	hasData := false
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		name, ok := token.(string)
+		if err != nil || !ok {
+			return rateLimit, hasData
+		}
+		if name == rateLimitAlias {
+			var field GithubRateLimitModel
+			if decoder.Decode(&field) != nil {
+				return rateLimit, hasData
+			}
+			rateLimit = &field
+			continue
+		}
+		var field dataField
+		if decoder.Decode(&field) != nil {
+			return rateLimit, hasData
+		}
+		hasData = hasData || !field.null
+	}
+	return rateLimit, hasData
+}
+
+// dataField notes whether a top level field of the data came back null,
+// without keeping or decoding its value.
+type dataField struct {
+	null bool
+}
+
+func (f *dataField) UnmarshalJSON(raw []byte) error {
+	f.null = string(raw) == "null"
+	return nil
+}
+
+// decodeErrorData tells apart the ways decoding a response fails: a body
+// over the limit, one cut short or that couldn't be read, and one that
+// isn't what we expect.
+func decodeErrorData(err error) *ErrorData {
+	var readError bodyReadError
+	switch {
+	case errors.Is(err, errResponseTooLarge):
+		return &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GraphQlRequestErrorResponseTooLarge),
+			Cause:   err,
+		}
+	// The decoder hands back end of input as it is, anything wrapping it
+	// came from a result of ours.
+	case err == io.ErrUnexpectedEOF, err == io.EOF, errors.As(err, &readError):
+		return &ErrorData{
+			Source:  ErrorDataSourceUnknown,
+			Message: string(GraphQlRequestErrorTruncatedResponse),
+			Cause:   err,
+		}
+	}
+	// Anything else is a body we can't make sense of, be it invalid JSON,
+	// a mismatched type or a result failing to decode its own field.
+	return &ErrorData{
+		Source:  ErrorDataSourceUs,
+		Message: string(GraphQlRequestErrorInvalidResponse),
+		Cause:   err,
+	}
+}
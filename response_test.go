@@ -0,0 +1,255 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestResponseSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestResponseSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestResponseSuite))
+}
+
+func (uts *UnitTestResponseSuite) TestDecoding() {
+
+	var tests = []struct {
+		testName         string
+		status           int
+		body             string
+		maxResponseBytes int64
+		allowPartialData bool
+		// cutShort promises more body than is sent, so reading it fails
+		cutShort   bool
+		assertFunc func(t *testing.T, result graphQlTestResult, err *main.ErrorData)
+	}{
+		{
+			testName:         "body exactly at the limit",
+			status:           http.StatusOK,
+			body:             testResponse,
+			maxResponseBytes: int64(len(testResponse)),
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+			},
+		},
+		{
+			testName: "errors decoded into the result",
+			status:   http.StatusOK,
+			body:     `{"data":null,"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User."}]}`,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrNotFound)
+				asserts.Len(result.Errors, 1)
+				asserts.Equal(result.Errors, err.GithubErrors)
+			},
+		},
+		{
+			testName: "null errors",
+			status:   http.StatusOK,
+			body:     `{"data":{"viewer":{"login":"octocat"}},"errors":null}`,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+			},
+		},
+		{
+			testName:         "partial data",
+			status:           http.StatusOK,
+			body:             `{"data":{"viewer":{"login":"octocat"}},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible."}]}`,
+			allowPartialData: true,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+				asserts.Len(result.Errors, 1)
+			},
+		},
+		{
+			testName:         "rate limit field is not partial data",
+			status:           http.StatusOK,
+			body:             `{"data":{"viewer":null,"readmeStudioRateLimit":{"cost":1,"remaining":4999}},"errors":[{"type":"FORBIDDEN","message":"Resource not accessible."}]}`,
+			allowPartialData: true,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				assert.ErrorIs(t, err, main.ErrForbidden)
+			},
+		},
+		{
+			testName:         "body over the limit",
+			status:           http.StatusOK,
+			body:             `{"data":{"viewer":{"login":"` + strings.Repeat("a", 1024) + `"}}}`,
+			maxResponseBytes: 512,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrResponseTooLarge)
+				asserts.NotErrorIs(err, main.ErrInvalidResponse)
+				asserts.Equal(main.ErrorDataSourceUs, err.Source)
+			},
+		},
+		{
+			testName:         "error body over the limit",
+			status:           http.StatusBadRequest,
+			body:             `{"message":"` + strings.Repeat("a", 1024) + `"}`,
+			maxResponseBytes: 512,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				assert.ErrorIs(t, err, main.ErrResponseTooLarge)
+			},
+		},
+		{
+			testName: "truncated body",
+			status:   http.StatusOK,
+			body:     `{"data":{"viewer":{"lo`,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrTruncatedResponse)
+				asserts.NotErrorIs(err, main.ErrInvalidResponse)
+			},
+		},
+		{
+			testName: "connection cut short",
+			status:   http.StatusOK,
+			body:     `{"data":{"viewer":{"lo`,
+			cutShort: true,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(err, main.ErrTruncatedResponse)
+				asserts.Equal(main.ErrorDataSourceUnknown, err.Source)
+			},
+		},
+		{
+			testName: "not json",
+			status:   http.StatusOK,
+			body:     `<html>oops</html>`,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				assert.ErrorIs(t, err, main.ErrInvalidResponse)
+			},
+		},
+		{
+			testName: "empty error body",
+			status:   http.StatusBadGateway,
+			assertFunc: func(t *testing.T, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Equal(main.ErrorDataSourceGithub, err.Source)
+				asserts.Equal(http.StatusText(http.StatusBadGateway), err.Message)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if v.cutShort {
+					w.Header().Set("Content-Length", strconv.Itoa(len(v.body)+64))
+				}
+				w.WriteHeader(v.status)
+				w.Write([]byte(v.body))
+			}))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.MaxResponseBytes = v.maxResponseBytes
+			client.AllowPartialData = v.allowPartialData
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(context.Background(), testQuery, nil, &result)
+
+			// Assert
+			if v.assertFunc != nil {
+				v.assertFunc(t, result, errorData(err))
+			}
+		})
+	}
+}
+
+// failingModel fails to decode itself, the way a model with a field of its
+// own type may.
+type failingModel struct{}
+
+func (failingModel) UnmarshalJSON([]byte) error {
+	return errors.New("failingModel can't be decoded")
+}
+
+func (uts *UnitTestResponseSuite) TestResultFailsToDecode() {
+
+	var tests = []struct {
+		testName string
+		body     string
+		doFunc   func(client *main.GraphQlClient) error
+	}{
+		{
+			testName: "result with its own UnmarshalJSON",
+			body:     testResponse,
+			doFunc: func(client *main.GraphQlClient) error {
+				var result main.GithubResultModel[failingModel]
+				return client.Do(context.Background(), testQuery, nil, &result)
+			},
+		},
+		{
+			testName: "time field that isn't a time",
+			body:     `{"data":{"viewer":{"createdAt":"yesterday"}}}`,
+			doFunc: func(client *main.GraphQlClient) error {
+				var result main.GithubResultModel[struct {
+					Viewer struct {
+						CreatedAt time.Time `json:"createdAt"`
+					} `json:"viewer"`
+				}]
+				return client.Do(context.Background(), testQuery, nil, &result)
+			},
+		},
+		{
+			testName: "nil result",
+			body:     testResponse,
+			doFunc: func(client *main.GraphQlClient) error {
+				return client.Do(context.Background(), testQuery, nil, nil)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			asserts := assert.New(uts.T())
+
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.Write([]byte(v.body))
+			}))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.Retry = testRetryPolicy
+
+			// Act
+			err := errorData(v.doFunc(client))
+
+			// Assert
+			asserts.ErrorIs(err, main.ErrInvalidResponse)
+			asserts.NotErrorIs(err, main.ErrTruncatedResponse)
+			asserts.Equal(main.ErrorDataSourceUs, err.Source)
+			asserts.EqualValues(1, atomic.LoadInt32(&calls), "not retried")
+		})
+	}
+}
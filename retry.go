@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides how often and how long GraphQlClient waits before
+// trying a failed query again. A MaxAttempts below 2 disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    time.Minute,
+}
+
+const secondaryRateLimitMessage = "secondary rate limit"
+
+func (c *GraphQlClient) withRetries(ctx context.Context, query string, do func(info *attemptInfo) *ErrorData) *ErrorData {
+	maxAttempts := c.Retry.MaxAttempts
+	if maxAttempts < 1 || !isIdempotentQuery(query) {
+		maxAttempts = 1
+	}
+
+	var attempts []ErrorDataAttempt
+	for attempt := 0; ; attempt++ {
+		var info attemptInfo
+		errData := do(&info)
+		if errData == nil {
+			return nil
+		}
+
+		record := ErrorDataAttempt{
+			Source:     errData.Source,
+			Message:    errData.Message,
+			StatusCode: info.statusCode,
+		}
+		delay, retry := c.Retry.next(attempt, errData, info, time.Now())
+		if retry && attempt+1 < maxAttempts && fitsDeadline(ctx, delay) {
+			record.Delay = delay
+			attempts = append(attempts, record)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+				errData = contextErrorData(ctx, ctx)
+				record = ErrorDataAttempt{
+					Source:  errData.Source,
+					Message: errData.Message,
+				}
+			}
+		}
+
+		if maxAttempts > 1 {
+			errData.Attempts = append(attempts, record)
+		}
+		return errData
+	}
+}
+
+// next reports whether a failed attempt is worth repeating and how long to
+// wait first. attempt counts from zero.
+func (p RetryPolicy) next(attempt int, errData *ErrorData, info attemptInfo, now time.Time) (time.Duration, bool) {
+	switch errData.Source {
+	case ErrorDataSourceContext:
+		if errData.Message != string(GraphQlRequestErrorAttemptTimeout) {
+			return 0, false
+		}
+	case ErrorDataSourceUnknown:
+	case ErrorDataSourceGithub:
+		if errData.Message != string(GraphQlRequestErrorRateLimited) &&
+			!retryableStatus(info.statusCode, info.header, errData.Message) {
+			return 0, false
+		}
+	default:
+		if !retryableStatus(info.statusCode, info.header, errData.Message) {
+			return 0, false
+		}
+	}
+
+	if delay, ok := serverDelay(info.header, now); ok {
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			return 0, false
+		}
+		return delay, true
+	}
+	return p.backoff(attempt), true
+}
+
+// backoff is exponential in attempt with "equal jitter": the result lies
+// between half and all of the capped exponential delay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func retryableStatus(statusCode int, header http.Header, message string) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return notEmpty(header.Get("Retry-After")) ||
+			header.Get("X-RateLimit-Remaining") == "0" ||
+			strings.Contains(strings.ToLower(message), secondaryRateLimitMessage)
+	}
+	return false
+}
+
+// serverDelay reads the wait GitHub asked for, from Retry-After first and
+// from X-RateLimit-Reset once the primary budget is used up.
+func serverDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	if header == nil {
+		return 0, false
+	}
+	if retryAfter := header.Get("Retry-After"); notEmpty(retryAfter) {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return nonNegative(at.Sub(now)), true
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return nonNegative(time.Unix(reset, 0).Sub(now)), true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func fitsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Now().Add(delay).Before(deadline)
+}
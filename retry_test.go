@@ -0,0 +1,157 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestRetrySuite struct {
+	suite.Suite
+}
+
+func TestUnitTestRetrySuite(t *testing.T) {
+	suite.Run(t, new(UnitTestRetrySuite))
+}
+
+var testRetryPolicy = main.RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+func (uts *UnitTestRetrySuite) TestRetries() {
+
+	const testMutation = "mutation { addStar(input: {starrableId: \"1\"}) { clientMutationId } }"
+
+	var tests = []struct {
+		testName   string
+		query      string
+		responses  []func(w http.ResponseWriter)
+		assertFunc func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData)
+	}{
+		{
+			testName: "recovers after 503",
+			query:    testQuery,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				},
+				func(w http.ResponseWriter) {
+					w.Write([]byte(testResponse))
+				},
+			},
+			assertFunc: func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.EqualValues(2, calls)
+				asserts.Equal(testLogin, result.Data.Viewer.Login)
+			},
+		},
+		{
+			testName: "gives up after max attempts",
+			query:    testQuery,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusBadGateway)
+				},
+			},
+			assertFunc: func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.EqualValues(testRetryPolicy.MaxAttempts, calls)
+				asserts.Len(err.Attempts, testRetryPolicy.MaxAttempts)
+				for _, attempt := range err.Attempts {
+					asserts.Equal(http.StatusBadGateway, attempt.StatusCode)
+				}
+				asserts.Zero(err.Attempts[len(err.Attempts)-1].Delay)
+			},
+		},
+		{
+			testName: "honors Retry-After on secondary rate limit",
+			query:    testQuery,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+				},
+				func(w http.ResponseWriter) {
+					w.Write([]byte(testResponse))
+				},
+			},
+			assertFunc: func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.Nil(err)
+				asserts.EqualValues(2, calls)
+			},
+		},
+		{
+			testName: "does not retry plain 403",
+			query:    testQuery,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+				},
+			},
+			assertFunc: func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.EqualValues(1, calls)
+				asserts.Len(err.Attempts, 1)
+			},
+		},
+		{
+			testName: "does not retry mutations",
+			query:    testMutation,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusBadGateway)
+				},
+			},
+			assertFunc: func(t *testing.T, calls int32, result graphQlTestResult, err *main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.NotNil(err)
+				asserts.EqualValues(1, calls)
+				asserts.Empty(err.Attempts)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := int(atomic.AddInt32(&calls, 1)) - 1
+				if call >= len(v.responses) {
+					call = len(v.responses) - 1
+				}
+				v.responses[call](w)
+			}))
+			defer server.Close()
+
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.Retry = testRetryPolicy
+
+			// Act
+			var result graphQlTestResult
+			err := client.Do(context.Background(), v.query, nil, &result)
+
+			// Assert
+			v.assertFunc(t, atomic.LoadInt32(&calls), result, errorData(err))
+		})
+	}
+}
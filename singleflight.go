@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/abhisheksrocks/readme-studio/tracing"
+)
+
+// CallGroup lets concurrent identical queries share one upstream call.
+// Queries are identical when their operation, variables and the identity
+// of the client sending them are.
+type CallGroup struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// sharedCall is an upstream call in flight. It runs on a context of its
+// own, canceled once every caller waiting for it is gone, so no single
+// caller leaving cancels it for the others.
+type sharedCall struct {
+	done    chan struct{}
+	body    json.RawMessage
+	errData *ErrorData
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func NewCallGroup() *CallGroup {
+	return &CallGroup{calls: map[string]*sharedCall{}}
+}
+
+// InFlight reports how many distinct calls are running.
+func (g *CallGroup) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
+
+// Waiting reports how many callers wait for a call.
+func (g *CallGroup) Waiting() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	waiting := 0
+	for _, call := range g.calls {
+		waiting += call.waiters
+	}
+	return waiting
+}
+
+// do joins the call running under key, starting it with fn when there is
+// none, and decodes its response into result. It returns early with a
+// context ErrorData when ctx is done first.
+func (g *CallGroup) do(ctx context.Context, key string, fn func(ctx context.Context, body *json.RawMessage) *ErrorData, result interface{}) *ErrorData {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		// The span of the caller starting the call stays its parent.
+		callCtx, cancel := context.WithCancel(tracing.ContextWithSpan(context.Background(), tracing.SpanFromContext(ctx)))
+		call = &sharedCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go func() {
+			call.errData = fn(callCtx, &call.body)
+			cancel()
+
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			// A canceled call must not be joined by later callers, they
+			// start a new one instead.
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return contextErrorData(ctx, ctx)
+	}
+
+	if len(call.body) > 0 && json.Unmarshal(call.body, result) != nil {
+		return &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GraphQlRequestErrorInvalidResponse),
+		}
+	}
+	if call.errData == nil {
+		return nil
+	}
+	// Every caller gets an ErrorData of its own to keep.
+	errData := *call.errData
+	errData.Attempts = append([]ErrorDataAttempt(nil), call.errData.Attempts...)
+	return &errData
+}
@@ -0,0 +1,218 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestCallGroupSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestCallGroupSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestCallGroupSuite))
+}
+
+// gatedHandler counts requests and holds every one of them until release
+// is closed, or the client goes away, which it reports on canceled.
+func gatedHandler(calls *int32, release <-chan struct{}, canceled chan<- struct{}, response string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		io.ReadAll(r.Body)
+		select {
+		case <-release:
+			w.Write([]byte(response))
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		}
+	}
+}
+
+// waitInFlight waits until group runs calls calls, waited for by waiting
+// callers.
+func waitInFlight(t *testing.T, group *main.CallGroup, calls int, waiting int) {
+	assert.Eventually(t, func() bool {
+		return group.InFlight() == calls && group.Waiting() == waiting
+	}, time.Second, time.Millisecond)
+}
+
+func (uts *UnitTestCallGroupSuite) TestDo() {
+	tests := []struct {
+		testName  string
+		query     string
+		logins    []string
+		response  string
+		wantCalls int32
+		assertErr func(t *testing.T, errs []*main.ErrorData)
+	}{
+		{
+			testName:  "identical queries share a call",
+			query:     testQuery,
+			logins:    []string{testLogin, testLogin, testLogin},
+			response:  testResponse,
+			wantCalls: 1,
+			assertErr: func(t *testing.T, errs []*main.ErrorData) {
+				for _, err := range errs {
+					assert.Nil(t, err)
+				}
+			},
+		},
+		{
+			testName:  "different variables don't",
+			query:     testQuery,
+			logins:    []string{testLogin, "hubot"},
+			response:  testResponse,
+			wantCalls: 2,
+		},
+		{
+			testName:  "mutations are never shared",
+			query:     `mutation Star($login: String!) { addStar(input: {starrableId: $login}) { clientMutationId } }`,
+			logins:    []string{testLogin, testLogin},
+			response:  testResponse,
+			wantCalls: 2,
+		},
+		{
+			testName:  "every caller gets its own error",
+			query:     testQuery,
+			logins:    []string{testLogin, testLogin},
+			response:  `{"data":null,"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User"}]}`,
+			wantCalls: 1,
+			assertErr: func(t *testing.T, errs []*main.ErrorData) {
+				asserts := assert.New(t)
+				asserts.ErrorIs(errs[0], main.ErrNotFound)
+				asserts.ErrorIs(errs[1], main.ErrNotFound)
+				asserts.NotSame(errs[0], errs[1])
+			},
+		},
+	}
+	for _, tt := range tests {
+		uts.Run(tt.testName, func() {
+			t := uts.T()
+			asserts := assert.New(t)
+
+			// Arrange
+			var calls int32
+			release := make(chan struct{})
+			server := httptest.NewServer(gatedHandler(&calls, release, make(chan struct{}, len(tt.logins)), tt.response))
+			defer server.Close()
+			client := main.NewGraphQlClient(server.URL, server.Client())
+			client.InFlight = main.NewCallGroup()
+
+			// Act
+			results := make([]graphQlTestResult, len(tt.logins))
+			errs := make([]*main.ErrorData, len(tt.logins))
+			var wg sync.WaitGroup
+			for i, login := range tt.logins {
+				wg.Add(1)
+				go func(i int, login string) {
+					defer wg.Done()
+					errs[i] = errorData(client.Do(context.Background(), tt.query, map[string]string{"login": login}, &results[i]))
+				}(i, login)
+			}
+			asserts.Eventually(func() bool { return atomic.LoadInt32(&calls) == tt.wantCalls }, time.Second, time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			// Assert
+			asserts.Equal(tt.wantCalls, atomic.LoadInt32(&calls))
+			if tt.assertErr != nil {
+				tt.assertErr(t, errs)
+				return
+			}
+			for i := range tt.logins {
+				asserts.Nil(errs[i])
+				asserts.Equal(testLogin, results[i].Data.Viewer.Login)
+			}
+		})
+	}
+}
+
+func (uts *UnitTestCallGroupSuite) TestCancellation() {
+	t := uts.T()
+	asserts := assert.New(t)
+
+	// Arrange
+	var calls int32
+	release := make(chan struct{})
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(gatedHandler(&calls, release, canceled, testResponse))
+	defer server.Close()
+	client := main.NewGraphQlClient(server.URL, server.Client())
+	client.InFlight = main.NewCallGroup()
+	variables := map[string]string{"login": testLogin}
+
+	leavingCtx, leave := context.WithCancel(context.Background())
+	leftErr := make(chan *main.ErrorData)
+	go func() {
+		var result graphQlTestResult
+		leftErr <- errorData(client.Do(leavingCtx, testQuery, variables, &result))
+	}()
+	waitInFlight(t, client.InFlight, 1, 1)
+
+	var stayingResult graphQlTestResult
+	stayedErr := make(chan *main.ErrorData)
+	go func() {
+		stayedErr <- errorData(client.Do(context.Background(), testQuery, variables, &stayingResult))
+	}()
+	waitInFlight(t, client.InFlight, 1, 2)
+
+	// Act
+	leave()
+	err := <-leftErr
+	close(release)
+
+	// Assert
+	asserts.NotNil(err)
+	asserts.ErrorIs(err, main.ErrCanceled)
+	asserts.Nil(<-stayedErr, "the caller that stayed still gets the response")
+	asserts.Equal(testLogin, stayingResult.Data.Viewer.Login)
+	asserts.Equal(int32(1), atomic.LoadInt32(&calls))
+	asserts.Empty(canceled)
+}
+
+func (uts *UnitTestCallGroupSuite) TestCancellationOfEveryCaller() {
+	t := uts.T()
+	asserts := assert.New(t)
+
+	// Arrange
+	var calls int32
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(gatedHandler(&calls, make(chan struct{}), canceled, testResponse))
+	defer server.Close()
+	client := main.NewGraphQlClient(server.URL, server.Client())
+	client.InFlight = main.NewCallGroup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan *main.ErrorData, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var result graphQlTestResult
+			errs <- errorData(client.Do(ctx, testQuery, map[string]string{"login": testLogin}, &result))
+		}()
+	}
+	waitInFlight(t, client.InFlight, 1, 2)
+
+	// Act
+	cancel()
+
+	// Assert
+	asserts.ErrorIs(<-errs, main.ErrCanceled)
+	asserts.ErrorIs(<-errs, main.ErrCanceled)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		asserts.Fail("the shared call wasn't canceled once every caller left")
+	}
+	waitInFlight(t, client.InFlight, 0, 0)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenQuarantine is for how long a token GitHub refused is left
+// out of rotation.
+const DefaultTokenQuarantine = 10 * time.Minute
+
+// TokenPool spreads requests over several tokens. Every request goes out
+// with the token that has the most rate-limit budget left, and a token
+// answered with 401 is quarantined for Quarantine before it is tried again.
+// Tokens whose remaining budget is down to Reserve are not used until their
+// budget resets.
+type TokenPool struct {
+	Reserve    int
+	Quarantine time.Duration
+
+	mu     sync.Mutex
+	tokens []*pooledToken
+}
+
+type pooledToken struct {
+	token            string
+	rateLimit        *RateLimitTracker
+	quarantinedUntil time.Time
+}
+
+// TokenStatus is what the pool knows about one of its tokens. Identity
+// stands in for the token, which is never handed out.
+type TokenStatus struct {
+	Identity         string
+	Budget           RateLimitBudget
+	BudgetKnown      bool
+	QuarantinedUntil time.Time
+}
+
+func NewTokenPool(tokens []string, reserve int, quarantine time.Duration) *TokenPool {
+	pool := &TokenPool{
+		Reserve:    reserve,
+		Quarantine: quarantine,
+	}
+	for _, token := range tokens {
+		pool.tokens = append(pool.tokens, &pooledToken{
+			token:     token,
+			rateLimit: NewRateLimitTracker(reserve, RateLimitActionRefuse),
+		})
+	}
+	return pool
+}
+
+// Identity stands for the whole pool in cache keys: any token of the pool
+// may answer a query, so they all share entries.
+func (p *TokenPool) Identity() string {
+	tokens := make([]string, len(p.tokens))
+	for i, pooled := range p.tokens {
+		tokens[i] = pooled.token
+	}
+	sum := sha256.Sum256([]byte(strings.Join(tokens, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p *TokenPool) Status() []TokenStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make([]TokenStatus, len(p.tokens))
+	for i, pooled := range p.tokens {
+		budget, known := pooled.rateLimit.Snapshot()
+		statuses[i] = TokenStatus{
+			Identity:         tokenIdentity([]RequestHeader{makeAuthorizationHeader(pooled.token)}),
+			Budget:           budget,
+			BudgetKnown:      known,
+			QuarantinedUntil: pooled.quarantinedUntil,
+		}
+	}
+	return statuses
+}
+
+// pick returns the token to use next, skipping the ones in tried. A token
+// we know nothing about yet beats any known budget, so every token gets
+// measured early on.
+func (p *TokenPool) pick(now time.Time, tried map[*pooledToken]bool) (*pooledToken, *ErrorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *pooledToken
+	bestRemaining := 0
+	quarantined := 0
+	for _, pooled := range p.tokens {
+		if tried[pooled] {
+			continue
+		}
+		if now.Before(pooled.quarantinedUntil) {
+			quarantined++
+			continue
+		}
+		budget, known := pooled.rateLimit.Snapshot()
+		remaining := budget.Remaining
+		if !known || !now.Before(budget.Reset) {
+			remaining = math.MaxInt
+		} else if remaining <= p.Reserve {
+			continue
+		}
+		if best == nil || remaining > bestRemaining {
+			best, bestRemaining = pooled, remaining
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+	if quarantined > 0 && quarantined+len(tried) == len(p.tokens) {
+		return nil, &ErrorData{
+			Source:  ErrorDataSourceUs,
+			Message: string(GraphQlRequestErrorUnauthorized),
+		}
+	}
+	return nil, &ErrorData{
+		Source:  ErrorDataSourceUs,
+		Message: string(GraphQlRequestErrorRateLimitBudget),
+	}
+}
+
+func (p *TokenPool) quarantine(pooled *pooledToken, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pooled.quarantinedUntil = now.Add(p.Quarantine)
+}
+
+// Middleware authorizes every request with a token of the pool and keeps
+// track of its budget, holding the estimated cost of the request on it
+// until the response is in. A token whose budget is held by requests in
+// flight is passed over, and a request refused with 401 is sent again
+// with the next best token, as long as there is one and the body can be
+// replayed.
+func (p *TokenPool) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			claim := rateLimitClaimFrom(request.Context())
+			cost := claim.estimatedCost()
+			tried := map[*pooledToken]bool{}
+			sent := false
+			pooled, errData := p.pick(time.Now(), tried)
+			if errData != nil {
+				return nil, errData
+			}
+			for {
+				tried[pooled] = true
+				if pooled.rateLimit.reserve(request.Context(), cost) != nil {
+					if pooled, errData = p.pick(time.Now(), tried); errData != nil {
+						return nil, errData
+					}
+					continue
+				}
+				attempt := request.Clone(request.Context())
+				if sent {
+					body, err := request.GetBody()
+					if err != nil {
+						pooled.rateLimit.settle(cost)
+						return nil, err
+					}
+					attempt.Body = body
+				}
+				header := makeAuthorizationHeader(pooled.token)
+				attempt.Header.Set(header.key, header.value)
+
+				sent = true
+				claim.observedBy(pooled.rateLimit)
+				response, err := next.RoundTrip(attempt)
+				if err != nil {
+					pooled.rateLimit.settle(cost)
+					return response, err
+				}
+				pooled.rateLimit.observeHeader(response.Header, time.Now())
+				pooled.rateLimit.settle(cost)
+				if response.StatusCode != http.StatusUnauthorized {
+					return response, nil
+				}
+				p.quarantine(pooled, time.Now())
+				if request.GetBody == nil {
+					return response, nil
+				}
+				if pooled, errData = p.pick(time.Now(), tried); errData != nil {
+					return response, nil
+				}
+				response.Body.Close()
+			}
+		})
+	}
+}
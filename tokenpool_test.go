@@ -0,0 +1,218 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestTokenPoolSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestTokenPoolSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestTokenPoolSuite))
+}
+
+// tokenBudgets answers with the remaining budget of the token a request
+// carries, and with 401 for tokens it doesn't know. Every token seen is
+// appended to used.
+func tokenBudgets(budgets map[string]int, mu *sync.Mutex, used *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+		mu.Lock()
+		*used = append(*used, token)
+		mu.Unlock()
+		remaining, ok := budgets[token]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Write([]byte(testResponse))
+	}
+}
+
+func (uts *UnitTestTokenPoolSuite) TestTokenPool() {
+
+	var tests = []struct {
+		testName   string
+		tokens     []string
+		budgets    map[string]int
+		requests   int
+		assertFunc func(t *testing.T, used []string, errs []*main.ErrorData, pool *main.TokenPool)
+	}{
+		{
+			testName: "prefers token with most budget",
+			tokens:   []string{"low", "high"},
+			budgets:  map[string]int{"low": 100, "high": 4000},
+			requests: 4,
+			assertFunc: func(t *testing.T, used []string, errs []*main.ErrorData, pool *main.TokenPool) {
+				asserts := assert.New(t)
+				asserts.Equal([]string{"low", "high", "high", "high"}, used)
+				for _, status := range pool.Status() {
+					asserts.True(status.BudgetKnown)
+				}
+			},
+		},
+		{
+			testName: "skips tokens down to the reserve",
+			tokens:   []string{"drained"},
+			budgets:  map[string]int{"drained": 10},
+			requests: 2,
+			assertFunc: func(t *testing.T, used []string, errs []*main.ErrorData, pool *main.TokenPool) {
+				asserts := assert.New(t)
+				asserts.Equal([]string{"drained"}, used)
+				asserts.Nil(errs[0])
+				asserts.ErrorIs(errs[1], main.ErrRateLimitBudget)
+			},
+		},
+		{
+			testName: "quarantines refused token and resends",
+			tokens:   []string{"revoked", "valid"},
+			budgets:  map[string]int{"valid": 4000},
+			requests: 2,
+			assertFunc: func(t *testing.T, used []string, errs []*main.ErrorData, pool *main.TokenPool) {
+				asserts := assert.New(t)
+				asserts.Equal([]string{"revoked", "valid", "valid"}, used)
+				asserts.Nil(errs[0])
+				asserts.Nil(errs[1])
+				asserts.True(pool.Status()[0].QuarantinedUntil.After(time.Now()))
+			},
+		},
+		{
+			testName: "every token refused",
+			tokens:   []string{"revoked", "expired"},
+			requests: 2,
+			assertFunc: func(t *testing.T, used []string, errs []*main.ErrorData, pool *main.TokenPool) {
+				asserts := assert.New(t)
+				asserts.Equal([]string{"revoked", "expired"}, used)
+				asserts.ErrorIs(errs[0], main.ErrUnauthorized)
+				asserts.Equal(main.ErrorDataSourceGithub, errs[0].Source)
+				asserts.ErrorIs(errs[1], main.ErrUnauthorized)
+				asserts.Equal(main.ErrorDataSourceUs, errs[1].Source)
+			},
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			t := uts.T()
+
+			// Arrange
+			var mu sync.Mutex
+			var used []string
+			server := httptest.NewServer(tokenBudgets(v.budgets, &mu, &used))
+			defer server.Close()
+
+			pool := main.NewTokenPool(v.tokens, 50, time.Minute)
+			client := main.NewGraphQlClient(server.URL, server.Client(), pool.Middleware())
+
+			// Act
+			errs := make([]*main.ErrorData, v.requests)
+			for i := range errs {
+				var result graphQlTestResult
+				errs[i] = errorData(client.Do(context.Background(), testQuery, nil, &result))
+			}
+
+			// Assert
+			mu.Lock()
+			defer mu.Unlock()
+			v.assertFunc(t, used, errs, pool)
+		})
+	}
+}
+
+func (uts *UnitTestTokenPoolSuite) TestTokensHeldByCallsInFlight() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	var mu sync.Mutex
+	var used []string
+	var calls int32
+	release := make(chan struct{})
+	budgets := tokenBudgets(map[string]int{"a": 51, "b": 51}, &mu, &used)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > 2 {
+			<-release
+		}
+		budgets(w, r)
+	}))
+	defer server.Close()
+
+	pool := main.NewTokenPool([]string{"a", "b"}, 50, time.Minute)
+	client := main.NewGraphQlClient(server.URL, server.Client(), pool.Middleware())
+	for i := 0; i < 2; i++ {
+		var result graphQlTestResult
+		uts.Require().Nil(client.Do(context.Background(), testQuery, nil, &result))
+	}
+
+	// Act
+	errs := make(chan *main.ErrorData)
+	for i := 0; i < 3; i++ {
+		go func() {
+			var result graphQlTestResult
+			errs <- errorData(client.Do(context.Background(), testQuery, nil, &result))
+		}()
+	}
+	var refused *main.ErrorData
+	select {
+	case refused = <-errs:
+	case <-time.After(5 * time.Second):
+		close(release)
+		uts.FailNow("a call past the budget of both tokens wasn't refused")
+	}
+	close(release)
+	sent := []*main.ErrorData{<-errs, <-errs}
+
+	// Assert
+	asserts.ErrorIs(refused, main.ErrRateLimitBudget)
+	asserts.Equal([]*main.ErrorData{nil, nil}, sent)
+	mu.Lock()
+	defer mu.Unlock()
+	asserts.ElementsMatch([]string{"a", "b", "a", "b"}, used)
+}
+
+func (uts *UnitTestTokenPoolSuite) TestInjectedRateLimit() {
+	asserts := assert.New(uts.T())
+
+	// Arrange
+	server := fakegithub.New(fakegithub.DefaultFixtures())
+	defer server.Close()
+	server.SetRateLimit(5000, 4000, time.Now().Add(time.Hour))
+	pool := main.NewTokenPool([]string{"token"}, 50, time.Minute)
+	client := main.NewGraphQlClient(server.Endpoint(), server.Client(), pool.Middleware())
+	client.InjectRateLimit = true
+
+	// Act
+	_, err := main.FetchRepositoryCard(context.Background(), client, "async_button", fakeGithubOwner)
+
+	// Assert
+	asserts.Nil(err)
+	status := pool.Status()[0]
+	asserts.True(status.BudgetKnown)
+	asserts.Equal(3999, status.Budget.Remaining)
+	asserts.Equal(1, status.Budget.Cost, "the cost is only in the injected field")
+}
+
+func (uts *UnitTestTokenPoolSuite) TestIdentity() {
+	asserts := assert.New(uts.T())
+	asserts.Equal(main.NewTokenPool([]string{"a", "b"}, 0, 0).Identity(), main.NewTokenPool([]string{"a", "b"}, 0, 0).Identity())
+	asserts.NotEqual(main.NewTokenPool([]string{"a", "b"}, 0, 0).Identity(), main.NewTokenPool([]string{"a"}, 0, 0).Identity())
+}
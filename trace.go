@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/abhisheksrocks/readme-studio/tracing"
+)
+
+const (
+	TraceExporterEnvKey           = "README_STUDIO_TRACE_EXPORTER"
+	TraceExporterEnvKeyHelperText = "Where tracing spans go: \"" + TraceExporterStdout + "\" writes them to stdout as JSON lines, " +
+		"\"" + TraceExporterOTLP + "\" sends them to an OpenTelemetry collector. Tracing is off when unset."
+	OTLPEndpointEnvKey           = "README_STUDIO_OTLP_ENDPOINT"
+	OTLPEndpointEnvKeyHelperText = "OTLP/HTTP traces endpoint of the collector, " + tracing.DefaultOTLPEndpoint + " by default."
+)
+
+const (
+	TraceExporterStdout = "stdout"
+	TraceExporterOTLP   = "otlp"
+)
+
+// ServiceName is the service.name traces are exported under.
+const ServiceName = "readme-studio"
+
+type TraceError string
+
+const TraceErrorUnknownExporter TraceError = "unknown trace exporter, expected \"" + TraceExporterStdout + "\" or \"" + TraceExporterOTLP + "\""
+
+func (e TraceError) Error() string {
+	return string(e)
+}
+
+// CardTypeRepository is the card showing a single repository.
+const CardTypeRepository = "repository"
+
+// Names of the spans and attributes the client records.
+const (
+	spanCard       = "card"
+	spanCall       = "graphql.call"
+	spanAttempt    = "graphql.attempt"
+	spanDecode     = "graphql.decode"
+	spanCacheGet   = "cache.lookup"
+	spanPage       = "pagination.page"
+	spanCardRender = "card.render"
+
+	attributeCardType      = "card.type"
+	attributeOperation     = "graphql.operation"
+	attributeCost          = "graphql.cost"
+	attributeEstimatedCost = "graphql.cost.estimated"
+	attributeAttempt       = "graphql.attempt"
+	attributeStatusCode    = "http.status_code"
+	attributeErrorSource   = "error.source"
+	attributeCacheHit      = "cache.hit"
+	attributePage          = "pagination.page"
+	attributePageItems     = "pagination.items"
+)
+
+// NewTraceExporter builds the exporter named by exporter. endpoint only
+// matters to TraceExporterOTLP, where empty stands for the default one.
+func NewTraceExporter(exporter string, endpoint string) (tracing.Exporter, error) {
+	switch strings.ToLower(strings.TrimSpace(exporter)) {
+	case TraceExporterStdout:
+		return tracing.NewJSONExporter(os.Stdout), nil
+	case TraceExporterOTLP:
+		return tracing.NewOTLPExporter(endpoint, ServiceName), nil
+	}
+	return nil, TraceErrorUnknownExporter
+}
+
+// endSpan marks span as failed with err, or as a success without it, and
+// ends it.
+func endSpan(span *tracing.Span, err error) {
+	var errData *ErrorData
+	switch {
+	case errors.As(err, &errData):
+		span.SetAttributes(tracing.String(attributeErrorSource, string(errData.Source)))
+		if errData.StatusCode != 0 {
+			span.SetAttributes(tracing.Int(attributeStatusCode, errData.StatusCode))
+		}
+		span.SetStatus(tracing.StatusError, errData.Message)
+	case err != nil:
+		span.SetStatus(tracing.StatusError, err.Error())
+	default:
+		span.SetStatus(tracing.StatusOK, "")
+	}
+	span.End()
+}
+
+// callSpan is the span of the call ctx belongs to, nil when c doesn't
+// trace.
+func (c *GraphQlClient) callSpan(ctx context.Context) *tracing.Span {
+	if c.Tracer == nil {
+		return nil
+	}
+	return tracing.SpanFromContext(ctx)
+}
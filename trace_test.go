@@ -0,0 +1,204 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+	"github.com/abhisheksrocks/readme-studio/fakegithub"
+	"github.com/abhisheksrocks/readme-studio/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestTraceSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestTraceSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestTraceSuite))
+}
+
+// spanRecorder keeps every span exported to it.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) Export(ctx context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// named returns the spans called name, in the order they ended.
+func (r *spanRecorder) named(name string) []tracing.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var spans []tracing.SpanData
+	for _, span := range r.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func attributeValue(span tracing.SpanData, key string) any {
+	for _, attribute := range span.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+func (uts *UnitTestTraceSuite) TestClientSpans() {
+	asserts := assert.New(uts.T())
+
+	server := fakegithub.New(fakegithub.DefaultFixtures())
+	defer server.Close()
+	recorder := new(spanRecorder)
+	client := main.NewGraphQlClient(server.Endpoint(), server.Client())
+	client.Tracer = tracing.NewTracer(recorder)
+	client.Cache = main.NewMemoryCache(10)
+	client.CacheTTL = time.Minute
+	client.RateLimit = main.NewRateLimitTracker(0, main.RateLimitActionRefuse)
+	client.InjectRateLimit = true
+
+	ctx, parent := client.Tracer.Start(context.Background(), "card", tracing.SpanKindInternal)
+	_, err := main.FetchRepositoryCard(ctx, client, "async_button", fakeGithubOwner)
+	uts.Require().Nil(err)
+	_, err = main.FetchRepositoryCard(ctx, client, "async_button", fakeGithubOwner)
+	uts.Require().Nil(err)
+	_, err = main.FetchRepositoryCard(ctx, client, "missing", fakeGithubOwner)
+	uts.Require().NotNil(err)
+	parent.End()
+	uts.Require().Nil(client.Tracer.Shutdown(context.Background()))
+
+	calls := recorder.named("graphql.call")
+	uts.Require().Len(calls, 3)
+	for _, call := range calls {
+		asserts.Equal(parent.SpanContext().SpanID, call.ParentSpanID)
+		asserts.Equal("GithubRepositoryCard", attributeValue(call, "graphql.operation"))
+		asserts.Equal(int64(1), attributeValue(call, "graphql.cost.estimated"))
+	}
+	asserts.Equal(tracing.StatusOK, calls[0].Status)
+	asserts.Equal(int64(1), attributeValue(calls[0], "graphql.cost"))
+	asserts.Equal(tracing.StatusOK, calls[1].Status)
+	asserts.Nil(attributeValue(calls[1], "graphql.cost"))
+	asserts.Equal(tracing.StatusError, calls[2].Status)
+	asserts.Equal(string(main.GraphQlRequestErrorNotFound), calls[2].StatusMessage)
+	asserts.Equal(string(main.ErrorDataSourceGithub), attributeValue(calls[2], "error.source"))
+
+	lookups := recorder.named("cache.lookup")
+	uts.Require().Len(lookups, 3)
+	asserts.Equal([]any{false, true, false}, []any{
+		attributeValue(lookups[0], "cache.hit"),
+		attributeValue(lookups[1], "cache.hit"),
+		attributeValue(lookups[2], "cache.hit"),
+	})
+	asserts.Equal(calls[1].SpanID, lookups[1].ParentSpanID)
+
+	// The cached call sends nothing.
+	attempts := recorder.named("graphql.attempt")
+	uts.Require().Len(attempts, 2)
+	asserts.Equal(calls[0].SpanID, attempts[0].ParentSpanID)
+	asserts.Equal(tracing.SpanKindClient, attempts[0].Kind)
+	asserts.Equal(int64(1), attributeValue(attempts[0], "graphql.attempt"))
+	asserts.Equal(int64(http.StatusOK), attributeValue(attempts[0], "http.status_code"))
+	asserts.Equal(tracing.StatusError, attempts[1].Status)
+
+	decodes := recorder.named("graphql.decode")
+	uts.Require().Len(decodes, 2)
+	asserts.Equal(attempts[0].SpanID, decodes[0].ParentSpanID)
+}
+
+func (uts *UnitTestTraceSuite) TestRetriedAttempts() {
+	asserts := assert.New(uts.T())
+
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}}}`))
+	}))
+	defer server.Close()
+	recorder := new(spanRecorder)
+	client := main.NewGraphQlClient(server.URL, server.Client())
+	client.Tracer = tracing.NewTracer(recorder)
+	client.Retry = main.RetryPolicy{MaxAttempts: 2}
+
+	var result graphQlTestResult
+	uts.Require().Nil(client.Do(context.Background(), testQuery, nil, &result))
+	uts.Require().Nil(client.Tracer.Flush(context.Background()))
+
+	attempts := recorder.named("graphql.attempt")
+	uts.Require().Len(attempts, 2)
+	asserts.Equal(int64(1), attributeValue(attempts[0], "graphql.attempt"))
+	asserts.Equal(int64(http.StatusBadGateway), attributeValue(attempts[0], "http.status_code"))
+	asserts.Equal(string(main.ErrorDataSourceGithub), attributeValue(attempts[0], "error.source"))
+	asserts.Equal(tracing.StatusError, attempts[0].Status)
+	asserts.Equal(int64(2), attributeValue(attempts[1], "graphql.attempt"))
+	asserts.Equal(tracing.StatusOK, attempts[1].Status)
+	asserts.Equal(attempts[0].ParentSpanID, attempts[1].ParentSpanID)
+}
+
+func (uts *UnitTestTraceSuite) TestPaginationSpans() {
+	asserts := assert.New(uts.T())
+
+	server := httptest.NewServer(http.HandlerFunc(repositoriesHandler))
+	defer server.Close()
+	recorder := new(spanRecorder)
+	client := main.NewGraphQlClient(server.URL, server.Client())
+	client.Tracer = tracing.NewTracer(recorder)
+	paginator := main.NewUserRepositoriesPaginator(client, testLogin, 0)
+	paginator.PageSize = 2
+
+	for page := range paginator.Pages(context.Background()) {
+		uts.Require().Nil(page.Err)
+	}
+	uts.Require().Nil(client.Tracer.Flush(context.Background()))
+
+	pages, calls := recorder.named("pagination.page"), recorder.named("graphql.call")
+	uts.Require().Len(pages, 3)
+	uts.Require().Len(calls, 3)
+	for i, page := range pages {
+		asserts.Equal(int64(i+1), attributeValue(page, "pagination.page"))
+		asserts.Equal("GithubUserRepositories", attributeValue(page, "graphql.operation"))
+		asserts.Equal(page.SpanID, calls[i].ParentSpanID)
+	}
+	asserts.Equal(int64(1), attributeValue(pages[2], "pagination.items"))
+}
+
+func (uts *UnitTestTraceSuite) TestNewTraceExporter() {
+	asserts := assert.New(uts.T())
+
+	exporter, err := main.NewTraceExporter("stdout", "")
+	asserts.Nil(err)
+	asserts.IsType(&tracing.JSONExporter{}, exporter)
+
+	exporter, err = main.NewTraceExporter("OTLP", "")
+	uts.Require().Nil(err)
+	asserts.Equal(tracing.DefaultOTLPEndpoint, exporter.(*tracing.OTLPExporter).Endpoint)
+
+	_, err = main.NewTraceExporter("zipkin", "")
+	asserts.ErrorIs(err, main.TraceErrorUnknownExporter)
+}
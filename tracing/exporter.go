@@ -0,0 +1,237 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var kindNames = map[SpanKind]string{
+	SpanKindInternal: "internal",
+	SpanKindServer:   "server",
+	SpanKindClient:   "client",
+}
+
+var statusNames = map[Status]string{
+	StatusUnset: "unset",
+	StatusOK:    "ok",
+	StatusError: "error",
+}
+
+// JSONExporter writes every span as a JSON object on a line of its own,
+// for reading traces off stdout or a log file.
+type JSONExporter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewJSONExporter(writer io.Writer) *JSONExporter {
+	return &JSONExporter{writer: writer}
+}
+
+type jsonSpan struct {
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	TraceID       TraceID        `json:"traceId"`
+	SpanID        SpanID         `json:"spanId"`
+	ParentSpanID  string         `json:"parentSpanId,omitempty"`
+	Start         time.Time      `json:"start"`
+	DurationMs    float64        `json:"durationMs"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Status        string         `json:"status"`
+	StatusMessage string         `json:"statusMessage,omitempty"`
+}
+
+func (e *JSONExporter) Export(ctx context.Context, spans []SpanData) error {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, span := range spans {
+		line := jsonSpan{
+			Name:          span.Name,
+			Kind:          kindNames[span.Kind],
+			TraceID:       span.TraceID,
+			SpanID:        span.SpanID,
+			Start:         span.Start,
+			DurationMs:    float64(span.End.Sub(span.Start)) / float64(time.Millisecond),
+			Status:        statusNames[span.Status],
+			StatusMessage: span.StatusMessage,
+		}
+		if span.ParentSpanID.IsValid() {
+			line.ParentSpanID = span.ParentSpanID.String()
+		}
+		if len(span.Attributes) > 0 {
+			line.Attributes = make(map[string]any, len(span.Attributes))
+			for _, attribute := range span.Attributes {
+				line.Attributes[attribute.Key] = attribute.Value
+			}
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.writer.Write(buffer.Bytes())
+	return err
+}
+
+func (e *JSONExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// DefaultOTLPEndpoint is where a collector running next to readme-studio
+// takes traces over OTLP/HTTP.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+// OTLPExporter posts spans to an OpenTelemetry collector over OTLP/HTTP,
+// in the JSON encoding of the protocol.
+type OTLPExporter struct {
+	Endpoint string
+	// ServiceName is the service.name of the resource the spans belong to.
+	ServiceName string
+	// Headers go with every request, for collectors that want a key.
+	Headers    http.Header
+	HTTPClient *http.Client
+}
+
+func NewOTLPExporter(endpoint string, serviceName string) *OTLPExporter {
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+	return &OTLPExporter{
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// The types below mirror ExportTraceServiceRequest as the OTLP JSON
+// encoding spells it: IDs in hex and 64 bit integers as strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           TraceID        `json:"traceId"`
+	SpanID            SpanID         `json:"spanId"`
+	ParentSpanID      SpanID         `json:"parentSpanId"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    Status `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpAttribute(attribute Attribute) otlpKeyValue {
+	keyValue := otlpKeyValue{Key: attribute.Key}
+	switch value := attribute.Value.(type) {
+	case string:
+		keyValue.Value.StringValue = &value
+	case bool:
+		keyValue.Value.BoolValue = &value
+	case int64:
+		formatted := strconv.FormatInt(value, 10)
+		keyValue.Value.IntValue = &formatted
+	case float64:
+		keyValue.Value.DoubleValue = &value
+	default:
+		formatted := fmt.Sprint(value)
+		keyValue.Value.StringValue = &formatted
+	}
+	return keyValue
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	scopeSpans := otlpScopeSpans{
+		Scope: otlpScope{Name: e.ServiceName},
+		Spans: make([]otlpSpan, len(spans)),
+	}
+	for i, span := range spans {
+		converted := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.Status, Message: span.StatusMessage},
+		}
+		for _, attribute := range span.Attributes {
+			converted.Attributes = append(converted.Attributes, otlpAttribute(attribute))
+		}
+		scopeSpans.Spans[i] = converted
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{otlpAttribute(String("service.name", e.ServiceName))}},
+		ScopeSpans: []otlpScopeSpans{scopeSpans},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range e.Headers {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := e.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("otlp export to %s: %s", e.Endpoint, response.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader carries a SpanContext between processes, in the W3C
+// Trace Context format.
+const TraceparentHeader = "traceparent"
+
+const sampledFlag = 0x01
+
+// ParseTraceparent reads a traceparent header value such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. Versions after
+// 00 are read as far as 00 defines them, as the specification asks.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if !decodeLowerHex(sc.TraceID[:], parts[1]) || !decodeLowerHex(sc.SpanID[:], parts[2]) {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if !decodeLowerHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&sampledFlag != 0
+	return sc, sc.IsValid()
+}
+
+func decodeLowerHex(dst []byte, src string) bool {
+	if len(src) != hex.EncodedLen(len(dst)) || strings.ToLower(src) != src {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// Traceparent formats sc as a traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Extract returns ctx with the span of the traceparent in header as its
+// remote parent, or ctx itself when header has none that is valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Handler runs next in a server span that continues the trace of the
+// incoming request, so the spans next starts from the request context join
+// the trace of whoever called.
+func Handler(tracer *Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(Extract(r.Context(), r.Header), "HTTP "+r.Method, SpanKindServer)
+		defer span.End()
+		span.SetAttributes(
+			String("http.method", r.Method),
+			String("http.target", r.URL.RequestURI()),
+		)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(Int("http.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, http.StatusText(recorder.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(p)
+}
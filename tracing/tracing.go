@@ -0,0 +1,396 @@
+// Package tracing records spans of work and hands them to an Exporter in
+// batches. It follows the OpenTelemetry data model closely enough for its
+// spans to be sent to an OpenTelemetry collector, without depending on the
+// OpenTelemetry SDK.
+//
+// Every method is safe on a nil *Tracer and a nil *Span, which do nothing,
+// so code can be instrumented unconditionally and tracing turned off by
+// leaving the tracer nil.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, every span of a trace shares it.
+type TraceID [16]byte
+
+// SpanID identifies a span within its trace.
+type SpanID [8]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) MarshalText() ([]byte, error) {
+	if !id.IsValid() {
+		return []byte{}, nil
+	}
+	return []byte(id.String()), nil
+}
+
+// SpanContext is what a span passes on to its children, within the process
+// or across it in a traceparent header.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind tells what side of a call a span stands for. The values are the
+// ones of OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Status is the outcome of a span. The values are the ones of OTLP.
+type Status int
+
+const (
+	StatusUnset Status = 0
+	StatusOK    Status = 1
+	StatusError Status = 2
+)
+
+// Attribute is a key and a string, int64, float64 or bool value.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+func Float64(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is a finished span as exporters get it.
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        Status
+	StatusMessage string
+}
+
+// Span is a piece of work in progress. It is recorded once End is called.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SetAttributes adds attributes, replacing earlier ones with the same key.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	for _, attribute := range attributes {
+		replaced := false
+		for i := range s.data.Attributes {
+			if s.data.Attributes[i].Key == attribute.Key {
+				s.data.Attributes[i].Value = attribute.Value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.data.Attributes = append(s.data.Attributes, attribute)
+		}
+	}
+}
+
+// SetStatus sets the outcome of the span. message only goes with
+// StatusError.
+func (s *Span) SetStatus(status Status, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.data.Status = status
+	s.data.StatusMessage = ""
+	if status == StatusError {
+		s.data.StatusMessage = message
+	}
+}
+
+// End finishes the span and queues it for export. Calls after the first
+// are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.record(data)
+}
+
+// SpanContext returns what children of the span and outgoing requests
+// carry on.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID, Sampled: true}
+}
+
+type spanContextKey struct{}
+
+type remoteContextKey struct{}
+
+// ContextWithSpan returns ctx with span as the parent of spans started
+// from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span ctx carries, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns ctx with a span of another process,
+// usually read from a traceparent header, as the parent of spans started
+// from it.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteContextKey{}, sc)
+}
+
+// DefaultBatchSize is how many finished spans a Tracer holds before it
+// exports them without waiting for DefaultExportInterval.
+const DefaultBatchSize = 512
+
+// DefaultExportInterval is how often a Tracer exports what it holds.
+const DefaultExportInterval = 5 * time.Second
+
+// Exporter sends finished spans somewhere. A Tracer calls Export from one
+// goroutine at a time.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and exports them in the background. A failed export
+// drops its spans; the error is returned by the next Flush or Shutdown.
+type Tracer struct {
+	exporter Exporter
+
+	mu       sync.Mutex
+	pending  []SpanData
+	err      error
+	shutdown bool
+
+	exportMu sync.Mutex
+	full     chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go t.loop(DefaultExportInterval)
+	return t
+}
+
+// Start begins a span named name. Its parent is the span ctx carries, or
+// else the remote span it carries; without either it starts a new trace.
+// The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:   name,
+			Kind:   kind,
+			SpanID: newSpanID(),
+			Start:  time.Now(),
+		},
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID, span.data.ParentSpanID = parent.data.TraceID, parent.data.SpanID
+	} else if remote, ok := ctx.Value(remoteContextKey{}).(SpanContext); ok {
+		span.data.TraceID, span.data.ParentSpanID = remote.TraceID, remote.SpanID
+	} else {
+		span.data.TraceID = newTraceID()
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) record(data SpanData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.shutdown {
+		return
+	}
+	t.pending = append(t.pending, data)
+	if len(t.pending) >= DefaultBatchSize {
+		select {
+		case t.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) loop(interval time.Duration) {
+	defer close(t.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.full:
+		case <-t.stop:
+			return
+		}
+		t.export(context.Background())
+	}
+}
+
+func (t *Tracer) export(ctx context.Context) {
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+	}
+}
+
+func (t *Tracer) takeError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.err
+	t.err = nil
+	return err
+}
+
+// Flush exports the finished spans held right away.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.export(ctx)
+	return t.takeError()
+}
+
+// Shutdown exports what is left and shuts the exporter down. Spans ended
+// afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	if t.shutdown {
+		t.mu.Unlock()
+		return nil
+	}
+	t.shutdown = true
+	t.mu.Unlock()
+	close(t.stop)
+	<-t.stopped
+
+	t.export(ctx)
+	err := t.takeError()
+	if shutdownErr := t.exporter.Shutdown(ctx); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
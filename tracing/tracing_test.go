@@ -0,0 +1,293 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/abhisheksrocks/readme-studio/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnitTestTracingSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestTracingSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestTracingSuite))
+}
+
+// recordingExporter keeps every span it is given.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *recordingExporter) Export(ctx context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) byName(name string) []tracing.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []tracing.SpanData
+	for _, span := range e.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (uts *UnitTestTracingSuite) TestSpans() {
+	asserts := assert.New(uts.T())
+
+	exporter := new(recordingExporter)
+	tracer := tracing.NewTracer(exporter)
+
+	ctx, parent := tracer.Start(context.Background(), "parent", tracing.SpanKindInternal)
+	_, child := tracer.Start(ctx, "child", tracing.SpanKindClient)
+	child.SetAttributes(tracing.String("key", "first"), tracing.Int("count", 2))
+	child.SetAttributes(tracing.String("key", "second"))
+	child.SetStatus(tracing.StatusError, "failed")
+	child.End()
+	child.End()
+	parent.SetStatus(tracing.StatusOK, "ignored")
+	parent.End()
+	_, other := tracer.Start(context.Background(), "other", tracing.SpanKindInternal)
+	other.End()
+	uts.Require().Nil(tracer.Shutdown(context.Background()))
+
+	_, late := tracer.Start(context.Background(), "late", tracing.SpanKindInternal)
+	late.End()
+
+	uts.Require().Len(exporter.spans, 3)
+	parentData, childData, otherData := exporter.byName("parent")[0], exporter.byName("child")[0], exporter.byName("other")[0]
+	asserts.Equal(parentData.TraceID, childData.TraceID)
+	asserts.Equal(parentData.SpanID, childData.ParentSpanID)
+	asserts.False(parentData.ParentSpanID.IsValid())
+	asserts.NotEqual(parentData.TraceID, otherData.TraceID)
+	asserts.Equal([]tracing.Attribute{tracing.String("key", "second"), tracing.Int("count", 2)}, childData.Attributes)
+	asserts.Equal(tracing.StatusError, childData.Status)
+	asserts.Equal("failed", childData.StatusMessage)
+	asserts.Equal(tracing.SpanKindClient, childData.Kind)
+	asserts.Empty(parentData.StatusMessage)
+	asserts.False(childData.End.Before(childData.Start))
+}
+
+func (uts *UnitTestTracingSuite) TestNilTracer() {
+	var tracer *tracing.Tracer
+	ctx, span := tracer.Start(context.Background(), "span", tracing.SpanKindInternal)
+	span.SetAttributes(tracing.Bool("ok", true))
+	span.SetStatus(tracing.StatusOK, "")
+	span.End()
+	uts.Nil(span)
+	uts.Nil(tracing.SpanFromContext(ctx))
+	uts.Nil(tracer.Flush(ctx))
+	uts.Nil(tracer.Shutdown(ctx))
+}
+
+func (uts *UnitTestTracingSuite) TestParseTraceparent() {
+
+	var tests = []struct {
+		testName string
+		value    string
+		want     string
+		ok       bool
+	}{
+		{
+			testName: "sampled",
+			value:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			ok:       true,
+		},
+		{
+			testName: "not sampled",
+			value:    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			ok:       true,
+		},
+		{
+			testName: "later version with more fields",
+			value:    "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			want:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			ok:       true,
+		},
+		{testName: "empty"},
+		{testName: "version ff", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{testName: "extra field in version 00", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{testName: "upper case", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{testName: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{testName: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{testName: "short span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01"},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			// Act
+			sc, ok := tracing.ParseTraceparent(v.value)
+
+			// Assert
+			uts.Equal(v.ok, ok)
+			if v.ok {
+				uts.Equal(v.want, sc.Traceparent())
+			}
+		})
+	}
+}
+
+func (uts *UnitTestTracingSuite) TestHandler() {
+	asserts := assert.New(uts.T())
+
+	exporter := new(recordingExporter)
+	tracer := tracing.NewTracer(exporter)
+	server := httptest.NewServer(tracing.Handler(tracer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "render", tracing.SpanKindInternal)
+		span.End()
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})))
+	defer server.Close()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/card?user=octocat", nil)
+	uts.Require().Nil(err)
+	request.Header.Set(tracing.TraceparentHeader, traceparent)
+	response, err := http.DefaultClient.Do(request)
+	uts.Require().Nil(err)
+	response.Body.Close()
+	response, err = http.Get(server.URL + "/broken")
+	uts.Require().Nil(err)
+	response.Body.Close()
+	uts.Require().Nil(tracer.Flush(context.Background()))
+
+	servers, renders := exporter.byName("HTTP GET"), exporter.byName("render")
+	uts.Require().Len(servers, 2)
+	uts.Require().Len(renders, 2)
+	incoming, _ := tracing.ParseTraceparent(traceparent)
+	asserts.Equal(incoming.TraceID, servers[0].TraceID)
+	asserts.Equal(incoming.SpanID, servers[0].ParentSpanID)
+	asserts.Equal(tracing.SpanKindServer, servers[0].Kind)
+	asserts.Equal(servers[0].SpanID, renders[0].ParentSpanID)
+	asserts.Contains(servers[0].Attributes, tracing.String("http.target", "/card?user=octocat"))
+	asserts.Contains(servers[0].Attributes, tracing.Int("http.status_code", http.StatusOK))
+	asserts.Equal(tracing.StatusUnset, servers[0].Status)
+
+	asserts.NotEqual(incoming.TraceID, servers[1].TraceID)
+	asserts.Contains(servers[1].Attributes, tracing.Int("http.status_code", http.StatusInternalServerError))
+	asserts.Equal(tracing.StatusError, servers[1].Status)
+}
+
+func (uts *UnitTestTracingSuite) TestJSONExporter() {
+	asserts := assert.New(uts.T())
+
+	var buffer bytes.Buffer
+	tracer := tracing.NewTracer(tracing.NewJSONExporter(&buffer))
+	ctx, parent := tracer.Start(context.Background(), "parent", tracing.SpanKindInternal)
+	_, child := tracer.Start(ctx, "child", tracing.SpanKindClient)
+	child.SetAttributes(tracing.String("graphql.operation", "GithubRepositoryCard"), tracing.Int("graphql.cost", 1))
+	child.SetStatus(tracing.StatusError, "not found")
+	child.End()
+	parent.End()
+	uts.Require().Nil(tracer.Shutdown(context.Background()))
+
+	decoder := json.NewDecoder(&buffer)
+	var childLine, parentLine map[string]any
+	uts.Require().Nil(decoder.Decode(&childLine))
+	uts.Require().Nil(decoder.Decode(&parentLine))
+	asserts.Equal("child", childLine["name"])
+	asserts.Equal("client", childLine["kind"])
+	asserts.Equal("error", childLine["status"])
+	asserts.Equal("not found", childLine["statusMessage"])
+	asserts.Equal(map[string]any{"graphql.operation": "GithubRepositoryCard", "graphql.cost": float64(1)}, childLine["attributes"])
+	asserts.Equal(parentLine["spanId"], childLine["parentSpanId"])
+	asserts.Equal(parentLine["traceId"], childLine["traceId"])
+	asserts.Len(childLine["traceId"], 32)
+	asserts.NotContains(parentLine, "parentSpanId")
+}
+
+func (uts *UnitTestTracingSuite) TestOTLPExporter() {
+	asserts := assert.New(uts.T())
+
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	exporter := tracing.NewOTLPExporter(collector.URL+"/v1/traces", "readme-studio")
+	exporter.Headers = http.Header{"Api-Key": []string{"secret"}}
+	tracer := tracing.NewTracer(exporter)
+	_, span := tracer.Start(context.Background(), "graphql.call", tracing.SpanKindInternal)
+	span.SetAttributes(
+		tracing.String("graphql.operation", "GithubRepositoryCard"),
+		tracing.Int("graphql.cost", 1),
+		tracing.Bool("cache.hit", false),
+		tracing.Float64("ratio", 0.5),
+	)
+	span.SetStatus(tracing.StatusOK, "")
+	span.End()
+	uts.Require().Nil(tracer.Shutdown(context.Background()))
+
+	request, body := <-requests, <-bodies
+	asserts.Equal("/v1/traces", request.URL.Path)
+	asserts.Equal("application/json", request.Header.Get("Content-Type"))
+	asserts.Equal("secret", request.Header.Get("Api-Key"))
+
+	var export struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	uts.Require().Nil(json.Unmarshal(body, &export))
+	uts.Require().Len(export.ResourceSpans, 1)
+	asserts.Equal([]map[string]any{{"key": "service.name", "value": map[string]any{"stringValue": "readme-studio"}}},
+		export.ResourceSpans[0].Resource.Attributes)
+	uts.Require().Len(export.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+	exported := export.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	asserts.Equal("graphql.call", exported["name"])
+	asserts.Equal(float64(tracing.SpanKindInternal), exported["kind"])
+	asserts.Equal(map[string]any{"code": float64(tracing.StatusOK)}, exported["status"])
+	asserts.IsType("", exported["startTimeUnixNano"])
+	asserts.Equal([]any{
+		map[string]any{"key": "graphql.operation", "value": map[string]any{"stringValue": "GithubRepositoryCard"}},
+		map[string]any{"key": "graphql.cost", "value": map[string]any{"intValue": "1"}},
+		map[string]any{"key": "cache.hit", "value": map[string]any{"boolValue": false}},
+		map[string]any{"key": "ratio", "value": map[string]any{"doubleValue": 0.5}},
+	}, exported["attributes"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	tracer = tracing.NewTracer(tracing.NewOTLPExporter(failing.URL, "readme-studio"))
+	_, span = tracer.Start(context.Background(), "dropped", tracing.SpanKindInternal)
+	span.End()
+	asserts.NotNil(tracer.Flush(context.Background()))
+	asserts.Nil(tracer.Flush(context.Background()))
+	tracer.Shutdown(context.Background())
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	HTTPSProxyEnvKey           = "HTTPS_PROXY"
+	HTTPSProxyEnvKeyHelperText = "Proxy that requests to https endpoints go through, " +
+		"as http://proxy.example.com:3128 or socks5://proxy.example.com:1080."
+	HTTPProxyEnvKey           = "HTTP_PROXY"
+	HTTPProxyEnvKeyHelperText = "Proxy that requests to plain http endpoints go through, in the format of " +
+		HTTPSProxyEnvKey + "."
+	NoProxyEnvKey           = "NO_PROXY"
+	NoProxyEnvKeyHelperText = "Comma separated hosts reached without a proxy: names (which cover their subdomains, " +
+		"or only those with a leading dot), host:port pairs, IP addresses, CIDR ranges, or * for all."
+	GithubClientCertEnvKey           = "GITHUB_CLIENT_CERT"
+	GithubClientCertEnvKeyHelperText = "PEM file of the client certificate shown to instances that require mutual TLS, " +
+		"it may hold the private key too."
+	GithubClientKeyEnvKey           = "GITHUB_CLIENT_KEY"
+	GithubClientKeyEnvKeyHelperText = "PEM file of the private key of " + GithubClientCertEnvKey + ", " +
+		"when the certificate file doesn't hold it."
+	MaxIdleConnsEnvKey                  = "README_STUDIO_MAX_IDLE_CONNS"
+	MaxIdleConnsEnvKeyHelperText        = "Most idle connections kept open over all hosts, 0 for no limit."
+	MaxIdleConnsPerHostEnvKey           = "README_STUDIO_MAX_IDLE_CONNS_PER_HOST"
+	MaxIdleConnsPerHostEnvKeyHelperText = "Most idle connections kept open to one host."
+	IdleConnTimeoutEnvKey               = "README_STUDIO_IDLE_CONN_TIMEOUT"
+	IdleConnTimeoutEnvKeyHelperText     = "How long an idle connection is kept open, as 90s or 2m, 0 to keep it until closed."
+)
+
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+type TransportError string
+
+const (
+	TransportErrorInvalidProxy           TransportError = "proxy must be an http, https or socks5 URL"
+	TransportErrorClientKeyWithoutCert   TransportError = "client key given without a client certificate"
+	TransportErrorInvalidIdleConns       TransportError = "idle connection limit must be a whole number, 0 or more"
+	TransportErrorInvalidIdleConnTimeout TransportError = "idle connection timeout must be a duration such as 90s, 0 or more"
+)
+
+func (e TransportError) Error() string {
+	return string(e)
+}
+
+// TransportConfig is how connections to GitHub are made. The zero value
+// goes direct, trusts the system pool and keeps no idle connections; start
+// from DefaultTransportConfig instead.
+type TransportConfig struct {
+	// ProxyURL is the proxy of https requests, none when empty. A URL
+	// without a scheme is an http proxy.
+	ProxyURL string
+	// HTTPProxyURL is the proxy of plain http requests, like ProxyURL.
+	HTTPProxyURL string
+	// NoProxy lists the hosts reached without a proxy, in the format of
+	// NO_PROXY. Loopback addresses never go through one.
+	NoProxy string
+	// CABundle is a PEM file of certificate authorities trusted next to the
+	// system ones.
+	CABundle string
+	// ClientCert and ClientKey are PEM files of the certificate shown for
+	// mutual TLS. ClientKey may be empty when ClientCert holds the key.
+	ClientCert string
+	ClientKey  string
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// DefaultTransportConfig goes direct with the idle connection limits of
+// http.DefaultTransport, but more connections kept per host.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+	}
+}
+
+// LoadTransportConfig reads the transport settings from the same
+// environment as readEnv, keeping the defaults for keys that aren't set.
+// The lower case proxy keys are honored like curl and Go do.
+func LoadTransportConfig(readEnv *ReadEnv) (TransportConfig, error) {
+	config := DefaultTransportConfig()
+	config.ProxyURL = lookupEither(readEnv, EnvKey{Key: HTTPSProxyEnvKey, UsedFor: HTTPSProxyEnvKeyHelperText})
+	config.HTTPProxyURL = lookupEither(readEnv, EnvKey{Key: HTTPProxyEnvKey, UsedFor: HTTPProxyEnvKeyHelperText})
+	config.NoProxy = lookupEither(readEnv, EnvKey{Key: NoProxyEnvKey, UsedFor: NoProxyEnvKeyHelperText})
+	config.CABundle, _ = readEnv.Lookup(EnvKey{Key: GithubCABundleEnvKey, UsedFor: GithubCABundleEnvKeyHelperText})
+	config.ClientCert, _ = readEnv.Lookup(EnvKey{Key: GithubClientCertEnvKey, UsedFor: GithubClientCertEnvKeyHelperText})
+	config.ClientKey, _ = readEnv.Lookup(EnvKey{Key: GithubClientKeyEnvKey, UsedFor: GithubClientKeyEnvKeyHelperText})
+
+	for _, limit := range []struct {
+		key   EnvKey
+		value *int
+	}{
+		{EnvKey{Key: MaxIdleConnsEnvKey, UsedFor: MaxIdleConnsEnvKeyHelperText}, &config.MaxIdleConns},
+		{EnvKey{Key: MaxIdleConnsPerHostEnvKey, UsedFor: MaxIdleConnsPerHostEnvKeyHelperText}, &config.MaxIdleConnsPerHost},
+	} {
+		value, err := readEnv.Lookup(limit.key)
+		if err != nil {
+			continue
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || parsed < 0 {
+			return TransportConfig{}, fmt.Errorf("%s: %w", limit.key.Key, TransportErrorInvalidIdleConns)
+		}
+		*limit.value = parsed
+	}
+
+	if value, err := readEnv.Lookup(EnvKey{Key: IdleConnTimeoutEnvKey, UsedFor: IdleConnTimeoutEnvKeyHelperText}); err == nil {
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			return TransportConfig{}, fmt.Errorf("%s: %w", IdleConnTimeoutEnvKey, TransportErrorInvalidIdleConnTimeout)
+		}
+		config.IdleConnTimeout = timeout
+	}
+	return config, nil
+}
+
+func lookupEither(readEnv *ReadEnv, key EnvKey) string {
+	if value, err := readEnv.Lookup(key); err == nil {
+		return value
+	}
+	key.Key = strings.ToLower(key.Key)
+	value, _ := readEnv.Lookup(key)
+	return value
+}
+
+// NewTransport builds the transport config describes, on top of the dial
+// and handshake timeouts of http.DefaultTransport.
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+
+	proxy, err := parseProxyURL(config.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	httpProxy, err := parseProxyURL(config.HTTPProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	proxyConfig := httpproxy.Config{
+		HTTPSProxy: proxy,
+		HTTPProxy:  httpProxy,
+		NoProxy:    config.NoProxy,
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		return proxyFunc(request.URL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if notEmpty(strings.TrimSpace(config.CABundle)) {
+		if tlsConfig.RootCAs, err = LoadCABundle(config.CABundle); err != nil {
+			return nil, err
+		}
+	}
+	if empty(config.ClientCert) && notEmpty(config.ClientKey) {
+		return nil, TransportErrorClientKeyWithoutCert
+	}
+	if notEmpty(config.ClientCert) {
+		keyFile := config.ClientKey
+		if empty(keyFile) {
+			keyFile = config.ClientCert
+		}
+		certificate, err := tls.LoadX509KeyPair(config.ClientCert, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// NewHTTPClient is an http.Client on the transport config describes. It
+// sets no timeout of its own, GraphQlClient bounds every request already.
+func NewHTTPClient(config TransportConfig) (*http.Client, error) {
+	transport, err := NewTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// parseProxyURL checks proxyURL and returns it with a scheme, for
+// httpproxy.Config. An empty proxyURL stays empty.
+func parseProxyURL(proxyURL string) (string, error) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if empty(proxyURL) {
+		return "", nil
+	}
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || empty(parsed.Host) {
+		return "", TransportErrorInvalidProxy
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	}
+	return "", TransportErrorInvalidProxy
+}
@@ -0,0 +1,288 @@
+package main_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "github.com/abhisheksrocks/readme-studio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/abhisheksrocks/readme-studio/mocks"
+)
+
+type UnitTestTransportSuite struct {
+	suite.Suite
+}
+
+func TestUnitTestTransportSuite(t *testing.T) {
+	suite.Run(t, new(UnitTestTransportSuite))
+}
+
+func (uts *UnitTestTransportSuite) TestLoadTransportConfig() {
+
+	withDefaults := func(change func(*main.TransportConfig)) main.TransportConfig {
+		config := main.DefaultTransportConfig()
+		change(&config)
+		return config
+	}
+
+	var tests = []struct {
+		testName    string
+		environment map[string]string
+		want        main.TransportConfig
+		err         error
+	}{
+		{
+			testName: "nothing set",
+			want:     main.DefaultTransportConfig(),
+		},
+		{
+			testName: "every key",
+			environment: map[string]string{
+				main.HTTPSProxyEnvKey:          "http://proxy.internal:3128",
+				main.HTTPProxyEnvKey:           "http://proxy.internal:3129",
+				main.NoProxyEnvKey:             ".internal",
+				main.GithubCABundleEnvKey:      "/etc/ca.pem",
+				main.GithubClientCertEnvKey:    "/etc/client.pem",
+				main.GithubClientKeyEnvKey:     "/etc/client.key",
+				main.MaxIdleConnsEnvKey:        "20",
+				main.MaxIdleConnsPerHostEnvKey: "5",
+				main.IdleConnTimeoutEnvKey:     "2m",
+			},
+			want: main.TransportConfig{
+				ProxyURL:            "http://proxy.internal:3128",
+				HTTPProxyURL:        "http://proxy.internal:3129",
+				NoProxy:             ".internal",
+				CABundle:            "/etc/ca.pem",
+				ClientCert:          "/etc/client.pem",
+				ClientKey:           "/etc/client.key",
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 5,
+				IdleConnTimeout:     2 * time.Minute,
+			},
+		},
+		{
+			testName:    "lower case proxy keys",
+			environment: map[string]string{"https_proxy": "proxy.internal:3128", "no_proxy": "*"},
+			want: withDefaults(func(config *main.TransportConfig) {
+				config.ProxyURL, config.NoProxy = "proxy.internal:3128", "*"
+			}),
+		},
+		{
+			testName:    "upper case proxy key wins",
+			environment: map[string]string{main.HTTPSProxyEnvKey: "upper:3128", "https_proxy": "lower:3128"},
+			want: withDefaults(func(config *main.TransportConfig) {
+				config.ProxyURL = "upper:3128"
+			}),
+		},
+		{
+			testName:    "negative idle connections",
+			environment: map[string]string{main.MaxIdleConnsPerHostEnvKey: "-1"},
+			err:         main.TransportErrorInvalidIdleConns,
+		},
+		{
+			testName:    "timeout without unit",
+			environment: map[string]string{main.IdleConnTimeoutEnvKey: "90"},
+			err:         main.TransportErrorInvalidIdleConnTimeout,
+		},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			// Arrange
+			m := new(mocks.ReadEnvEnvironment)
+			m.On("Getenv", mock.AnythingOfType("string")).Return(func(name string) string {
+				if name == main.GithubTokenEnvKey {
+					return "token"
+				}
+				return v.environment[name]
+			})
+			readEnv, err := main.NewReadEnv("", "", main.EnvKey{Key: main.GithubTokenEnvKey}, m)
+			uts.Require().Nil(err)
+
+			// Act
+			config, err := main.LoadTransportConfig(readEnv)
+
+			// Assert
+			if v.err != nil {
+				uts.ErrorIs(err, v.err)
+				return
+			}
+			uts.Nil(err)
+			uts.Equal(v.want, config)
+		})
+	}
+}
+
+func (uts *UnitTestTransportSuite) TestProxy() {
+
+	const proxy = "http://proxy.internal:3128"
+
+	var tests = []struct {
+		testName     string
+		proxyURL     string
+		httpProxyURL string
+		noProxy      string
+		url          string
+		wantProxy    bool
+	}{
+		{testName: "no proxy set", url: "https://api.github.com/graphql"},
+		{testName: "https request", proxyURL: proxy, url: "https://api.github.com/graphql", wantProxy: true},
+		{testName: "http request", proxyURL: proxy, url: "http://github.internal/api/graphql"},
+		{testName: "loopback", proxyURL: proxy, url: "https://127.0.0.1:8443/graphql"},
+		{testName: "localhost", proxyURL: proxy, url: "https://localhost/graphql"},
+		{testName: "everything exempt", proxyURL: proxy, noProxy: "*", url: "https://api.github.com/graphql"},
+		{testName: "exempt domain", proxyURL: proxy, noProxy: "example.com, github.com", url: "https://api.github.com/graphql"},
+		{testName: "http request through its own proxy", httpProxyURL: proxy, url: "http://github.internal/api/graphql", wantProxy: true},
+		{testName: "exempt subdomain with dot", proxyURL: proxy, noProxy: ".github.com", url: "https://api.github.com/graphql"},
+		{testName: "domain with dot covers subdomains only", proxyURL: proxy, noProxy: ".github.com", url: "https://github.com/graphql", wantProxy: true},
+		{testName: "suffix is not a subdomain", proxyURL: proxy, noProxy: "hub.com", url: "https://api.github.com/graphql", wantProxy: true},
+		{testName: "exempt port", proxyURL: proxy, noProxy: "github.internal:8443", url: "https://github.internal:8443/api/graphql"},
+		{testName: "other port", proxyURL: proxy, noProxy: "github.internal:8443", url: "https://github.internal/api/graphql", wantProxy: true},
+		{testName: "exempt address", proxyURL: proxy, noProxy: "10.0.0.7", url: "https://10.0.0.7/api/graphql"},
+		{testName: "exempt range", proxyURL: proxy, noProxy: "10.0.0.0/8", url: "https://10.1.2.3/api/graphql"},
+		{testName: "outside range", proxyURL: proxy, noProxy: "10.0.0.0/8", url: "https://192.168.1.1/api/graphql", wantProxy: true},
+		{testName: "proxy without scheme", proxyURL: "proxy.internal:3128", url: "https://api.github.com/graphql", wantProxy: true},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			// Arrange
+			config := main.DefaultTransportConfig()
+			config.ProxyURL, config.HTTPProxyURL, config.NoProxy = v.proxyURL, v.httpProxyURL, v.noProxy
+			transport, err := main.NewTransport(config)
+			uts.Require().Nil(err)
+			request, err := http.NewRequest(http.MethodPost, v.url, nil)
+			uts.Require().Nil(err)
+
+			// Act
+			proxyURL, err := transport.Proxy(request)
+
+			// Assert
+			uts.Nil(err)
+			if !v.wantProxy {
+				uts.Nil(proxyURL)
+				return
+			}
+			uts.Require().NotNil(proxyURL)
+			uts.Equal(proxy, proxyURL.String())
+		})
+	}
+}
+
+func (uts *UnitTestTransportSuite) TestNewTransport() {
+	asserts := assert.New(uts.T())
+
+	config := main.TransportConfig{MaxIdleConns: 3, MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Second}
+	transport, err := main.NewTransport(config)
+	uts.Require().Nil(err)
+	asserts.Equal(3, transport.MaxIdleConns)
+	asserts.Equal(2, transport.MaxIdleConnsPerHost)
+	asserts.Equal(time.Second, transport.IdleConnTimeout)
+	asserts.Equal(uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+
+	_, err = main.NewTransport(main.TransportConfig{ProxyURL: "ftp://proxy.internal"})
+	asserts.ErrorIs(err, main.TransportErrorInvalidProxy)
+
+	_, err = main.NewTransport(main.TransportConfig{ClientKey: "client.key"})
+	asserts.ErrorIs(err, main.TransportErrorClientKeyWithoutCert)
+}
+
+func (uts *UnitTestTransportSuite) TestClientCertificate() {
+	asserts := assert.New(uts.T())
+
+	dir := uts.T().TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	certificate, key := newClientCertificate(uts.T())
+	uts.Require().Nil(os.WriteFile(certFile, certificate, 0o600))
+	uts.Require().Nil(os.WriteFile(keyFile, key, 0o600))
+	bothFile := filepath.Join(dir, "both.pem")
+	uts.Require().Nil(os.WriteFile(bothFile, append(append([]byte{}, certificate...), key...), 0o600))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	bundle := filepath.Join(dir, "ca.pem")
+	uts.Require().Nil(os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	var tests = []struct {
+		testName string
+		config   main.TransportConfig
+		wantErr  bool
+	}{
+		{testName: "without certificate", config: main.TransportConfig{CABundle: bundle}, wantErr: true},
+		{testName: "separate key", config: main.TransportConfig{CABundle: bundle, ClientCert: certFile, ClientKey: keyFile}},
+		{testName: "key in certificate file", config: main.TransportConfig{CABundle: bundle, ClientCert: bothFile}},
+	}
+
+	for _, v := range tests {
+		v := v
+		uts.Run(v.testName, func() {
+			// Arrange
+			httpClient, err := main.NewHTTPClient(v.config)
+			uts.Require().Nil(err)
+
+			// Act
+			response, err := httpClient.Get(server.URL)
+
+			// Assert
+			if v.wantErr {
+				asserts.NotNil(err)
+				return
+			}
+			uts.Require().Nil(err)
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			uts.Require().Nil(err)
+			asserts.Equal("readme-studio", string(body))
+		})
+	}
+}
+
+// newClientCertificate is a self signed certificate and its key, both PEM.
+func newClientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "readme-studio"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}